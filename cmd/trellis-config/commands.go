@@ -0,0 +1,347 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/json"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
+// loadFlags are the flags of loading configs.
+type loadFlags struct {
+	env       bool
+	envPrefix string
+	keyFile   string
+	keyEnv    string
+}
+
+func (p *loadFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&p.env, "env", false, "resolve ${} references by environment variables")
+	fs.StringVar(&p.envPrefix, "env-prefix", "", "only resolve the environment variables with the prefix")
+	fs.StringVar(&p.keyFile, "key-file", "", "file of the base64 encoded secret key for encrypted values")
+	fs.StringVar(&p.keyEnv, "key-env", config.DefaultSecretKeyEnv, "environment variable of the base64 encoded secret key")
+}
+
+// showSecretsFlag registers the flag to print the decrypted values and values referencing them,
+// they are printed as <hidden> by default.
+func showSecretsFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("show-secrets", false, "print the decrypted values and values referencing them in plaintext")
+}
+
+func (p *loadFlags) load(filename string, opts ...config.OptionFunc) (config.Config, error) {
+	opts = append([]config.OptionFunc{config.OptionFile(filename), config.OptionSecretKeyEnv(p.keyEnv)}, opts...)
+	if p.env {
+		opts = append(opts, config.OptionENVAllowed(), config.OptionENVPrefix(p.envPrefix))
+	}
+	if p.keyFile != "" {
+		opts = append(opts, config.OptionSecretKeyFile(p.keyFile))
+	}
+	return config.NewConfigOptions(opts...)
+}
+
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: trellis-config %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, and checks the number of positional arguments is in [minArgs, maxArgs], maxArgs < 0 is unlimited.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return false
+	}
+	return true
+}
+
+func runRender(args []string, stdout, stderr io.Writer) int {
+	var (
+		lf     loadFlags
+		format string
+	)
+	fs := newFlagSet("render", "<file>", stderr)
+	lf.register(fs)
+	fs.StringVar(&format, "o", formatYAML, "output format: yaml or json")
+	showSecrets := showSecretsFlag(fs)
+	if !parseFlags(fs, args, 1, 1) {
+		return 2
+	}
+
+	c, err := lf.load(fs.Arg(0))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if err = output(stdout, values(c, *showSecrets), format); err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+func runGet(args []string, stdout, stderr io.Writer) int {
+	var (
+		lf     loadFlags
+		format string
+	)
+	fs := newFlagSet("get", "<file> <key>", stderr)
+	lf.register(fs)
+	fs.StringVar(&format, "o", formatYAML, "output format of maps and lists: yaml or json")
+	showSecrets := showSecretsFlag(fs)
+	if !parseFlags(fs, args, 2, 2) {
+		return 2
+	}
+
+	c, err := lf.load(fs.Arg(0))
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	key := fs.Arg(1)
+	value := c.GetInterface(key)
+	if value != nil && !*showSecrets {
+		value = config.Redact(c.GetConfig(key))[key]
+	}
+	switch value.(type) {
+	case nil:
+		return fail(stderr, errcode.Newf("%s: %s", key, config.ErrKeyNotFound))
+	case map[string]any, map[any]any, config.Options, []any:
+		err = output(stdout, value, format)
+	default:
+		_, err = fmt.Fprintln(stdout, value)
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+func runLint(args []string, stdout, stderr io.Writer) int {
+	var (
+		lf     loadFlags
+		schema string
+	)
+	fs := newFlagSet("lint", "<file>...", stderr)
+	lf.register(fs)
+	fs.StringVar(&schema, "schema", "", "JSON Schema file to check the values")
+	if !parseFlags(fs, args, 1, -1) {
+		return 2
+	}
+
+	var jsonSchema *config.JSONSchema
+	if schema != "" {
+		data, err := os.ReadFile(schema)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if jsonSchema, err = config.NewJSONSchema(data); err != nil {
+			return fail(stderr, errcode.Newf("%s: %v", schema, err))
+		}
+	}
+
+	code := 0
+	for _, filename := range fs.Args() {
+		c, err := lf.load(filename, config.OptionStrictReferences())
+		if err == nil && jsonSchema != nil {
+			err = c.Validate(jsonSchema)
+		}
+		if err == nil {
+			fmt.Fprintf(stdout, "%s: ok\n", filename)
+			continue
+		}
+
+		code = 1
+		if errs, ok := err.(errcode.Errors); ok {
+			for _, e := range errs {
+				fmt.Fprintf(stdout, "%s: %v\n", filename, e)
+			}
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %v\n", filename, err)
+	}
+	return code
+}
+
+// runDiff prints the changes from a to b, and exits with 1 if there are changes like diff(1).
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	var lf loadFlags
+	fs := newFlagSet("diff", "<a> <b>", stderr)
+	lf.register(fs)
+	showSecrets := showSecretsFlag(fs)
+	if !parseFlags(fs, args, 2, 2) {
+		return 2
+	}
+
+	a, err := lf.load(fs.Arg(0))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	b, err := lf.load(fs.Arg(1))
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	changes := config.Diff(a, b)
+	if *showSecrets {
+		changes = config.DiffSecrets(a, b)
+	}
+	if len(changes) == 0 {
+		return 0
+	}
+	fmt.Fprintln(stdout, changes)
+	return 1
+}
+
+func runKeygen(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("keygen", "", stderr)
+	if !parseFlags(fs, args, 0, 0) {
+		return 2
+	}
+
+	key, err := config.GenerateSecretKey()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	fmt.Fprintln(stdout, key)
+	return 0
+}
+
+func runEncrypt(args []string, _, stderr io.Writer) int {
+	var keyFile, keyEnv string
+	fs := newFlagSet("encrypt", "<file> <key>...", stderr)
+	fs.StringVar(&keyFile, "key-file", "", "file of the base64 encoded secret key")
+	fs.StringVar(&keyEnv, "key-env", config.DefaultSecretKeyEnv, "environment variable of the base64 encoded secret key")
+	if !parseFlags(fs, args, 2, -1) {
+		return 2
+	}
+
+	encoded := os.Getenv(keyEnv)
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return fail(stderr, config.ErrSecretKeyNotFound)
+	}
+
+	key, err := config.ParseSecretKey(encoded)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if err = config.EncryptFileKeys(fs.Arg(0), key, fs.Args()[1:]...); err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "error:", err)
+	return 2
+}
+
+func values(c config.Config, showSecrets bool) map[string]any {
+	if !showSecrets {
+		return config.Redact(c)
+	}
+	values := make(map[string]any)
+	for _, key := range c.GetKeys() {
+		values[key] = c.GetInterface(key)
+	}
+	return values
+}
+
+func output(w io.Writer, value any, format string) error {
+	value = normalize(value)
+	switch format {
+	case formatYAML:
+		buf := &bytes.Buffer{}
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	case formatJSON:
+		bs, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(bs))
+		return err
+	default:
+		return errcode.Newf("unknown output format %q", format)
+	}
+}
+
+// normalize converts the maps with non-string keys of YAML and the numbers of JSON for encoding.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = normalize(item)
+		}
+		return m
+	case config.Options:
+		return normalize(map[string]any(v))
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = normalize(item)
+		}
+		return list
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return value
+	}
+}
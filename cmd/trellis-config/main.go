@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// trellis-config renders, lints and queries the config files of github.com/go-trellis/common/config.
+//
+//	trellis-config render [-o yaml|json] app.yaml
+//	trellis-config get app.yaml db.host
+//	trellis-config lint [-schema schema.json] app.yaml
+//	trellis-config diff app.yaml app.next.yaml
+//	trellis-config keygen > config.key
+//	trellis-config encrypt -key-file config.key app.yaml db.password
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "render", usage: "resolve all #include and ${} references and print the final config", run: runRender},
+	{name: "get", usage: "print the value of a key", run: runGet},
+	{name: "lint", usage: "report circular includes, unresolved references and type mismatches", run: runLint},
+	{name: "diff", usage: "compare two configs", run: runDiff},
+	{name: "keygen", usage: "generate a secret key for encrypted values", run: runKeygen},
+	{name: "encrypt", usage: "encrypt the values of keys in a YAML or JSON file", run: runEncrypt},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	}
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: trellis-config <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "trellis-config <command> -h" for the flags of a command.`)
+}
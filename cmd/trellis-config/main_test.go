@@ -0,0 +1,179 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	testutils.Ok(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func runCommand(args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRenderAndGet(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "db.json", `{"db": {"port": 3306, "user": "root"}}`)
+	app := writeFile(t, dir, "app.yaml", `"#include": db.json
+db:
+  host: localhost
+  addr: "${db.host}:${db.port}"
+servers: [a, b]
+`)
+
+	code, stdout, _ := runCommand("render", app)
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, `db:
+  addr: localhost:3306
+  host: localhost
+  port: 3306
+  user: root
+servers:
+  - a
+  - b
+`, stdout)
+
+	code, stdout, _ = runCommand("render", "-o", "json", app)
+	testutils.Equals(t, 0, code)
+	testutils.Assert(t, strings.Contains(stdout, `"addr": "localhost:3306"`), "json output: %s", stdout)
+
+	code, stdout, _ = runCommand("get", app, "db.addr")
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "localhost:3306\n", stdout)
+
+	code, stdout, _ = runCommand("get", "-o", "json", app, "servers")
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "[\n  \"a\",\n  \"b\"\n]\n", stdout)
+
+	code, _, stderr := runCommand("get", app, "db.password")
+	testutils.Equals(t, 2, code)
+	testutils.Equals(t, "error: db.password: key not found\n", stderr)
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	good := writeFile(t, dir, "good.yaml", "db:\n  port: 3306\n")
+	refs := writeFile(t, dir, "refs.yaml", "db:\n  host: ${DB_HOST}\n  port: ${db.missing}\n")
+	writeFile(t, dir, "b.yaml", `"#include": a.yaml`)
+	circular := writeFile(t, dir, "a.yaml", `"#include": b.yaml`)
+	schema := writeFile(t, dir, "schema.json", `{"properties": {"db": {"properties": {"port": {"type": "integer"}}}}}`)
+
+	code, stdout, _ := runCommand("lint", good)
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, good+": ok\n", stdout)
+
+	code, stdout, _ = runCommand("lint", "-schema", schema, good, refs, circular)
+	testutils.Equals(t, 1, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	testutils.Equals(t, 4, len(lines), "lint output: %s", stdout)
+	testutils.Equals(t, good+": ok", lines[0])
+	testutils.Equals(t, refs+": db.host: unresolved reference: ${DB_HOST}", lines[1])
+	testutils.Equals(t, refs+": db.port: unresolved reference: ${db.missing}", lines[2])
+	testutils.Assert(t, strings.Contains(lines[3], "circular reference"), "circular include: %s", lines[3])
+
+	bad := writeFile(t, dir, "bad.yaml", "db:\n  port: abc\n")
+	code, stdout, _ = runCommand("lint", "-schema", schema, bad)
+	testutils.Equals(t, 1, code)
+	testutils.Equals(t, bad+": db.port: expected integer, got string\n", stdout)
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.yaml", "db:\n  host: localhost\n  port: 3306\n")
+	b := writeFile(t, dir, "b.json", `{"db": {"host": "localhost", "port": 3307, "user": "root"}}`)
+
+	code, stdout, _ := runCommand("diff", a, writeFile(t, dir, "a.json", `{"db": {"host": "localhost", "port": 3306}}`))
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "", stdout)
+
+	code, stdout, _ = runCommand("diff", a, b)
+	testutils.Equals(t, 1, code)
+	testutils.Equals(t, "~ db.port: 3306 -> 3307\n+ db.user: root\n", stdout)
+}
+
+func TestEncrypt(t *testing.T) {
+	dir := t.TempDir()
+
+	code, key, _ := runCommand("keygen")
+	testutils.Equals(t, 0, code)
+	keyFile := writeFile(t, dir, "config.key", key)
+	app := writeFile(t, dir, "app.yaml", "db:\n  password: p@ssword\n")
+
+	code, _, _ = runCommand("encrypt", "-key-file", keyFile, app, "db.password")
+	testutils.Equals(t, 0, code)
+
+	data, err := os.ReadFile(app)
+	testutils.Ok(t, err)
+	testutils.Assert(t, strings.Contains(string(data), "ENC[aes256-gcm,"), "password should be encrypted: %s", data)
+
+	code, stdout, _ := runCommand("get", "-key-file", keyFile, app, "db.password")
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "<hidden>\n", stdout)
+
+	code, stdout, _ = runCommand("get", "-key-file", keyFile, "-show-secrets", app, "db.password")
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "p@ssword\n", stdout)
+
+	writeFile(t, dir, "app.yaml", string(data)+"  dsn: root:${db.password}@localhost\n")
+	code, stdout, _ = runCommand("render", "-key-file", keyFile, app)
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "db:\n  dsn: <hidden>\n  password: <hidden>\n", stdout)
+
+	code, stdout, _ = runCommand("render", "-key-file", keyFile, "-show-secrets", app)
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "db:\n  dsn: root:p@ssword@localhost\n  password: p@ssword\n", stdout)
+
+	code, stdout, _ = runCommand("get", "-key-file", keyFile, app, "db")
+	testutils.Equals(t, 0, code)
+	testutils.Equals(t, "dsn: <hidden>\npassword: <hidden>\n", stdout)
+
+	next := writeFile(t, dir, "next.yaml", "db:\n  password: new-p@ssword\n")
+	code, stdout, _ = runCommand("diff", "-key-file", keyFile, app, next)
+	testutils.Equals(t, 1, code)
+	testutils.Equals(t, "- db.dsn: <hidden>\n~ db.password: <hidden> -> <hidden>\n", stdout)
+
+	code, stdout, _ = runCommand("diff", "-key-file", keyFile, "-show-secrets", app, next)
+	testutils.Equals(t, 1, code)
+	testutils.Equals(t, "- db.dsn: root:p@ssword@localhost\n~ db.password: p@ssword -> new-p@ssword\n", stdout)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, stderr := runCommand()
+	testutils.Equals(t, 2, code)
+	testutils.Assert(t, strings.Contains(stderr, "Usage: trellis-config"), "usage: %s", stderr)
+
+	code, _, stderr = runCommand("unknown")
+	testutils.Equals(t, 2, code)
+	testutils.Assert(t, strings.HasPrefix(stderr, `unknown command "unknown"`), "usage: %s", stderr)
+
+	code, _, _ = runCommand("get", "app.yaml")
+	testutils.Equals(t, 2, code)
+}
@@ -62,6 +62,13 @@ func OptionENVPrefix(prefix string) OptionFunc {
 	}
 }
 
+// OptionStrictReferences option function to fail on ${key} references to unset keys, instead of resolving them to nil.
+func OptionStrictReferences() OptionFunc {
+	return func(c *AdapterConfig) {
+		c.strictReferences = true
+	}
+}
+
 // Config manager data functions
 type Config interface {
 	// GetInterface get a object
@@ -99,8 +106,14 @@ type Config interface {
 	Object(model any, opts ...ObjOption) error
 	// GetValuesConfig get key's values if values can be Config, or panic
 	GetValuesConfig(key string) Config
-	// SetKeyValue set key's value into config
+	// SetKeyValue set key's value into config, the calling function is recorded in history
 	SetKeyValue(key string, value any) (err error)
+	// SetKeyValueBy set key's value into config, and record who or what changed it in history
+	SetKeyValueBy(key string, value any, by string) error
+	// Snapshot get a copy of values with the version of changes
+	Snapshot() *Snapshot
+	// History get the records of changes made by SetKeyValue and SetKeyValueBy
+	History() []Record
 	// Dump get all config
 	Dump() (bs []byte, err error)
 	// GetKeys get all keys
@@ -108,6 +121,8 @@ type Config interface {
 	// Copy deep copy configs
 	Copy() Config
 	IsEmpty() bool
+	// Validate check values by schema: struct with validate tags or *JSONSchema
+	Validate(schema any) error
 }
 
 type ObjOption func(*ObjOptions)
@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package config
 
 import (
+	"maps"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -33,10 +34,6 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-const (
-	includeReg = `\$\{([0-9|a-z|A-Z|_|-]|\.)+\}`
-)
-
 // AdapterConfig default config adapter
 type AdapterConfig struct {
 	ConfigFile   string
@@ -46,6 +43,8 @@ type AdapterConfig struct {
 	EnvPrefix  string
 	EnvAllowed bool
 
+	strictReferences bool
+
 	data []byte
 
 	readerType ReaderType
@@ -53,6 +52,21 @@ type AdapterConfig struct {
 	reader  Reader
 	locker  sync.RWMutex
 	configs map[string]any
+
+	// files are the absolute paths of the config file and all of its included files
+	files []string
+
+	// secret key for decrypting ENC[aes256-gcm,...] values
+	secretKey     []byte
+	secretKeyFile string
+	secretKeyEnv  string
+	// secrets are the keys of decrypted values and values referencing them, to their raw values for Dump
+	secrets map[string]string
+
+	// version is increased by every SetKeyValue, and the latest changes are kept in history
+	version     uint64
+	history     []Record
+	historySize int
 }
 
 // NewAdapterConfig return default config adapter
@@ -75,6 +89,19 @@ func NewAdapterConfig(filepath string) (Config, error) {
 }
 
 func (p *AdapterConfig) init(opts ...OptionFunc) (err error) {
+	if err = p.parse(opts...); err != nil {
+		return
+	}
+
+	if _, err = p.decryptValues("", p.configs); err != nil {
+		return
+	}
+
+	return p.interpolate()
+}
+
+// parse reads the raw values and the included files, the values are not decrypted or interpolated.
+func (p *AdapterConfig) parse(opts ...OptionFunc) (err error) {
 	for i := range opts {
 		opts[i](p)
 	}
@@ -89,13 +116,9 @@ func (p *AdapterConfig) init(opts ...OptionFunc) (err error) {
 		}
 	}
 
-	switch p.readerType {
-	case ReaderTypeJSON:
-		p.reader = NewJSONReader(ReaderOptionFilename(p.ConfigFile))
-	case ReaderTypeYAML:
-		p.reader = NewYAMLReader(ReaderOptionFilename(p.ConfigFile))
-	default:
-		return ErrNotSupportedReaderType
+	p.reader, err = newConfigReader(p.readerType, p.ConfigFile)
+	if err != nil {
+		return err
 	}
 
 	if len(p.ConfigString) > 0 {
@@ -119,7 +142,11 @@ func (p *AdapterConfig) init(opts ...OptionFunc) (err error) {
 		return
 	}
 
-	return p.copyDollarSymbol(&p.configs)
+	p.files = make([]string, 0, len(visitedFiles))
+	for name := range visitedFiles {
+		p.files = append(p.files, name)
+	}
+	return
 }
 
 // GetKeys get map keys
@@ -142,12 +169,22 @@ func (p *AdapterConfig) copy() *AdapterConfig {
 
 	valuesMap := values.(map[string]any)
 	return &AdapterConfig{
-		ConfigFile:   p.ConfigFile,
-		ConfigString: p.ConfigString,
-		ConfigStruct: p.ConfigStruct,
-		readerType:   p.readerType,
-		reader:       p.reader,
-		configs:      valuesMap,
+		ConfigFile:       p.ConfigFile,
+		ConfigString:     p.ConfigString,
+		ConfigStruct:     p.ConfigStruct,
+		EnvPrefix:        p.EnvPrefix,
+		EnvAllowed:       p.EnvAllowed,
+		strictReferences: p.strictReferences,
+		readerType:       p.readerType,
+		reader:           p.reader,
+		configs:          valuesMap,
+		files:            append([]string(nil), p.files...),
+		secretKey:        p.secretKey,
+		secretKeyFile:    p.secretKeyFile,
+		secretKeyEnv:     p.secretKeyEnv,
+		secrets:          maps.Clone(p.secrets),
+		version:          p.version,
+		historySize:      p.historySize,
 	}
 }
 
@@ -181,7 +218,7 @@ func (p *AdapterConfig) GetInterface(key string, defValue ...any) (res any) {
 		return ErrInvalidKey
 	}
 
-	v, err = p.getKeyValue(key)
+	v, err = p.GetKeyValue(key)
 	return
 }
 
@@ -336,7 +373,7 @@ func (p *AdapterConfig) GetFloatList(key string) []float64 {
 
 // GetMap get map value
 func (p *AdapterConfig) GetMap(key string) Options {
-	vm, err := p.getKeyValue(key)
+	vm, err := p.GetKeyValue(key)
 	if err != nil {
 		return nil
 	}
@@ -363,7 +400,7 @@ func (p *AdapterConfig) GetMap(key string) Options {
 
 // GetConfig return object config in p.configs by key
 func (p *AdapterConfig) GetConfig(key string) Config {
-	vm, err := p.getKeyValue(key)
+	vm, err := p.GetKeyValue(key)
 	if err != nil {
 		return nil
 	}
@@ -380,7 +417,7 @@ func (p *AdapterConfig) ToObject(key string, model any) error {
 		err error
 	)
 	if key != "" {
-		vm, err = p.getKeyValue(key)
+		vm, err = p.GetKeyValue(key)
 		if err != nil {
 			return err
 		}
@@ -392,7 +429,7 @@ func (p *AdapterConfig) ToObject(key string, model any) error {
 	case ReaderTypeJSON:
 		bs, _ := json.Marshal(vm)
 		err = json.Unmarshal(bs, model)
-	case ReaderTypeYAML:
+	default:
 		bs, _ := yaml.Marshal(vm)
 		err = yaml.Unmarshal(bs, model)
 	}
@@ -409,7 +446,7 @@ func (p *AdapterConfig) Object(model any, opts ...ObjOption) error {
 		err error
 	)
 	if options.Key != "" {
-		vm, err = p.getKeyValue(options.Key)
+		vm, err = p.GetKeyValue(options.Key)
 		if err != nil {
 			return err
 		}
@@ -421,7 +458,7 @@ func (p *AdapterConfig) Object(model any, opts ...ObjOption) error {
 	case ReaderTypeJSON:
 		bs, _ := json.Marshal(vm)
 		err = json.Unmarshal(bs, model)
-	case ReaderTypeYAML:
+	default:
 		bs, _ := yaml.Marshal(vm)
 		err = yaml.Unmarshal(bs, model)
 	}
@@ -446,20 +483,19 @@ func (p *AdapterConfig) GetKeyValue(key string) (vm any, err error) {
 
 // SetKeyValue set key value into p.configs
 func (p *AdapterConfig) SetKeyValue(key string, value any) (err error) {
-	if len(key) == 0 {
-		return ErrInvalidKey
-	}
-	p.locker.Lock()
-	defer p.locker.Unlock()
-	return p.setKeyValue(key, value)
+	return p.SetKeyValueBy(key, value, caller())
 }
 
-// Dump return p.configs' bytes
+// Dump return p.configs' bytes, the decrypted values are dumped encrypted,
+// and the values referencing them are dumped as their ${...} expressions.
 func (p *AdapterConfig) Dump() (bs []byte, err error) {
 	p.locker.Lock()
 	defer p.locker.Unlock()
 
-	return p.reader.Dump(p.configs)
+	if len(p.secrets) == 0 {
+		return p.reader.Dump(p.configs)
+	}
+	return p.reader.Dump(dumpSecrets(p.secrets, "", DeepCopy(p.configs)))
 }
 
 // Copy return a copy
@@ -468,6 +504,8 @@ func (p *AdapterConfig) Copy() Config {
 }
 
 func (p *AdapterConfig) IsEmpty() bool {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
 	return len(p.configs) == 0
 }
 
@@ -576,13 +614,8 @@ func (p *AdapterConfig) processIncludes(configs *map[string]any, visitedFiles ma
 			return errcode.Newf("failed to read include file %s: %v", includePath, err)
 		}
 
-		var includedReader Reader
-		switch includedConfig.readerType {
-		case ReaderTypeJSON:
-			includedReader = NewJSONReader(ReaderOptionFilename(absIncludePath))
-		case ReaderTypeYAML:
-			includedReader = NewYAMLReader(ReaderOptionFilename(absIncludePath))
-		default:
+		includedReader, err := newConfigReader(includedConfig.readerType, absIncludePath)
+		if err != nil {
 			return errcode.Newf("unsupported reader type for include file %s", includePath)
 		}
 
@@ -598,11 +631,6 @@ func (p *AdapterConfig) processIncludes(configs *map[string]any, visitedFiles ma
 			return err
 		}
 
-		// Process ${variable} substitution in included config before merging
-		if err := includedConfig.copyDollarSymbol(&includedConfig.configs); err != nil {
-			return errcode.Newf("failed to process variables in include file %s: %v", includePath, err)
-		}
-
 		// Merge included config into current config (included values override existing ones)
 		p.mergeConfigs(configs, includedConfig.configs)
 	}
@@ -613,12 +641,17 @@ func (p *AdapterConfig) processIncludes(configs *map[string]any, visitedFiles ma
 // mergeConfigs merges source config into target config.
 // Values from source override values in target.
 func (p *AdapterConfig) mergeConfigs(target *map[string]any, source map[string]any) {
+	mergeValues(*target, source)
+}
+
+// mergeValues merges source values into target values recursively.
+func mergeValues(target, source map[string]any) {
 	for key, sourceValue := range source {
-		targetValue, exists := (*target)[key]
+		targetValue, exists := target[key]
 
 		if !exists {
 			// Key doesn't exist in target, just add it
-			(*target)[key] = DeepCopy(sourceValue)
+			target[key] = DeepCopy(sourceValue)
 			continue
 		}
 
@@ -628,11 +661,10 @@ func (p *AdapterConfig) mergeConfigs(target *map[string]any, source map[string]a
 
 		if targetIsMap && sourceIsMap {
 			// Both are maps, merge recursively
-			p.mergeConfigs(&targetMap, sourceMap)
-			(*target)[key] = targetMap
+			mergeValues(targetMap, sourceMap)
 		} else {
 			// Not both maps, source overrides target
-			(*target)[key] = DeepCopy(sourceValue)
+			target[key] = DeepCopy(sourceValue)
 		}
 	}
 }
@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/go-trellis/common/utils/types"
+)
+
+// ChangeType is the type of a changed key
+type ChangeType string
+
+// Change types
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Change is a changed leaf key, the key of list item is like servers[0].host
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  any
+	New  any
+}
+
+// String returns the change like: ~ db.port: 3306 -> 3307
+func (p Change) String() string {
+	switch p.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %v", p.Key, p.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %v", p.Key, p.Old)
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", p.Key, p.Old, p.New)
+	}
+}
+
+// Changes are the changes sorted by keys
+type Changes []Change
+
+// String returns the changes line by line
+func (p Changes) String() string {
+	lines := make([]string, 0, len(p))
+	for _, c := range p {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff returns the changes of leaf keys from a to b in nested maps and lists,
+// numbers of different types are equal by value, values of types.Secret,
+// decrypted ENC[...] values and values referencing them are redacted as types.Hidden.
+func Diff(a, b Config) Changes {
+	secrets := make(map[string]string)
+	maps.Copy(secrets, secretKeysOf(a))
+	maps.Copy(secrets, secretKeysOf(b))
+	return diffValues(configValues(a), configValues(b), secrets)
+}
+
+// DiffSecrets returns the changes like Diff, but the decrypted values and values referencing them are in plaintext.
+func DiffSecrets(a, b Config) Changes {
+	return diffValues(configValues(a), configValues(b), nil)
+}
+
+func diffValues(a, b map[string]any, secrets map[string]string) Changes {
+	oldValues, newValues := make(map[string]any), make(map[string]any)
+	flattenDiff("", a, oldValues)
+	flattenDiff("", b, newValues)
+
+	var changes Changes
+	for k, ov := range oldValues {
+		nv, ok := newValues[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: k, Type: ChangeRemoved, Old: redactKey(isSecretKey(secrets, k), ov)})
+		case !valueEqual(ov, nv):
+			if isSecret(ov) || isSecret(nv) || isSecretKey(secrets, k) {
+				ov, nv = types.Hidden, types.Hidden
+			}
+			changes = append(changes, Change{Key: k, Type: ChangeChanged, Old: ov, New: nv})
+		}
+	}
+	for k, nv := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			changes = append(changes, Change{Key: k, Type: ChangeAdded, New: redactKey(isSecretKey(secrets, k), nv)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flattenDiff sets the leaf values of maps and lists into values, exp: a.b, a.c[0].d
+func flattenDiff(prefix string, value any, values map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			values[prefix] = v
+		}
+		for k, item := range v {
+			flattenDiff(joinKey(prefix, k), item, values)
+		}
+	case Options:
+		flattenDiff(prefix, map[string]any(v), values)
+	case map[any]any:
+		if len(v) == 0 && prefix != "" {
+			values[prefix] = v
+		}
+		for k, item := range v {
+			flattenDiff(joinKey(prefix, fmt.Sprint(k)), item, values)
+		}
+	case []any:
+		if len(v) == 0 {
+			values[prefix] = v
+		}
+		for i, item := range v {
+			flattenDiff(fmt.Sprintf("%s[%d]", prefix, i), item, values)
+		}
+	default:
+		values[prefix] = value
+	}
+}
+
+func isSecret(value any) bool {
+	switch value.(type) {
+	case types.Secret, *types.Secret:
+		return true
+	}
+	return false
+}
+
+// redactKey returns types.Hidden if the key of value is a secret key or value is a secret
+func redactKey(secret bool, value any) any {
+	if secret || isSecret(value) {
+		return types.Hidden
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/go-trellis/common/utils/types"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost
+  port: 3306
+  name: app
+servers:
+  - host: a
+  - host: b
+`))
+	testutils.Ok(t, err)
+
+	b := a.Copy()
+	testutils.Ok(t, b.SetKeyValue("db.port", 3307))
+	testutils.Ok(t, b.SetKeyValue("db.user", "root"))
+	testutils.Ok(t, b.SetKeyValue("db.password", types.Secret("p@ssword")))
+	testutils.Ok(t, b.SetKeyValue("servers", []any{map[string]any{"host": "a"}, map[string]any{"host": "c"}}))
+	m := b.GetMap("db")
+	delete(m, "name")
+
+	changes := config.Diff(a, b)
+	testutils.Equals(t, config.Changes{
+		{Key: "db.name", Type: config.ChangeRemoved, Old: "app"},
+		{Key: "db.password", Type: config.ChangeAdded, New: types.Hidden},
+		{Key: "db.port", Type: config.ChangeChanged, Old: 3306, New: 3307},
+		{Key: "db.user", Type: config.ChangeAdded, New: "root"},
+		{Key: "servers[1].host", Type: config.ChangeChanged, Old: "b", New: "c"},
+	}, changes)
+	testutils.Equals(t, `- db.name: app
++ db.password: <hidden>
+~ db.port: 3306 -> 3307
++ db.user: root
+~ servers[1].host: b -> c`, changes.String())
+
+	testutils.Equals(t, 0, len(config.Diff(a, a.Copy())))
+}
+
+func TestHistory(t *testing.T) {
+	c, err := config.NewConfigOptions(
+		config.OptionString(config.ReaderTypeYAML, "db:\n  port: 3306"),
+		config.OptionHistorySize(2))
+	testutils.Ok(t, err)
+
+	snapshot := c.Snapshot()
+	testutils.Equals(t, uint64(0), snapshot.Version)
+
+	testutils.Ok(t, c.SetKeyValue("db.port", 3307))
+	testutils.Ok(t, c.SetKeyValueBy("db.password", types.Secret("p@ssword"), "deploy-42"))
+	testutils.Ok(t, c.SetKeyValueBy("db.host", "db.local", "operator"))
+	testutils.NotOk(t, c.SetKeyValueBy("", "value", "operator"))
+
+	history := c.History()
+	testutils.Equals(t, 2, len(history))
+	testutils.Equals(t, uint64(2), history[0].Version)
+	testutils.Equals(t, "db.password", history[0].Key)
+	testutils.Equals(t, nil, history[0].Old)
+	testutils.Equals(t, types.Hidden, history[0].New)
+	testutils.Equals(t, "deploy-42", history[0].By)
+	testutils.Equals(t, "operator", history[1].By)
+
+	testutils.Equals(t, uint64(3), c.Snapshot().Version)
+	testutils.Equals(t, 3306, snapshot.GetInt("db.port"))
+	testutils.Equals(t, 3, len(config.Diff(snapshot, c)))
+
+	c, err = config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, "db:\n  port: 3306"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, c.SetKeyValue("db.port", 3307))
+	history = c.History()
+	testutils.Equals(t, 1, len(history))
+	testutils.Equals(t, 3306, history[0].Old)
+	testutils.Equals(t, "github.com/go-trellis/common/config_test.TestHistory", history[0].By)
+}
+
+func TestDiff_Encrypted(t *testing.T) {
+	key := make([]byte, config.SecretKeySize)
+	password, err := config.EncryptValue(key, "p@ssword")
+	testutils.Ok(t, err)
+
+	a, err := config.NewConfigOptions(config.OptionSecretKey(key), config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost
+  password: `+password+`
+  dsn: root:${db.password}@${db.host}
+`))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "p@ssword", a.GetString("db.password"))
+	testutils.Equals(t, "root:p@ssword@localhost", a.GetString("db.dsn"))
+
+	b := a.Copy()
+	testutils.Ok(t, b.SetKeyValue("db.host", "db.local"))
+	testutils.Ok(t, b.SetKeyValue("db.password", "new-p@ssword"))
+	testutils.Ok(t, b.SetKeyValue("db.dsn", "root:new-p@ssword@db.local"))
+
+	changes := config.Diff(a, b)
+	testutils.Equals(t, `~ db.dsn: <hidden> -> <hidden>
+~ db.host: localhost -> db.local
+~ db.password: <hidden> -> <hidden>`, changes.String())
+	testutils.Equals(t, "p@ssword", a.GetString("db.password"))
+
+	for _, r := range b.History() {
+		if r.Key == "db.host" {
+			continue
+		}
+		testutils.Equals(t, types.Hidden, r.Old)
+		testutils.Equals(t, types.Hidden, r.New)
+	}
+
+	changes = config.Diff(a, b.Snapshot())
+	testutils.Equals(t, 3, len(changes))
+	testutils.Equals(t, types.Hidden, changes[2].New)
+}
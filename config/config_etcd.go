@@ -0,0 +1,381 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-trellis/common/errors/errcode"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultEtcdTimeout is the default timeout for loading configs from etcd.
+const DefaultEtcdTimeout = 5 * time.Second
+
+// EtcdClient is the subset of etcd client operations required by the etcd config,
+// it is implemented by clients/etcd.Clientv3Facade.
+type EtcdClient interface {
+	clientv3.KV
+	clientv3.Watcher
+}
+
+// EtcdConfig is a config loaded from etcd, it is updated live by watching etcd.
+type EtcdConfig interface {
+	Config
+	Watcher
+}
+
+// EtcdOption etcd config option function type.
+type EtcdOption func(*EtcdOptions)
+
+// EtcdOptions etcd config options
+type EtcdOptions struct {
+	// Prefix loads all keys under prefix, /app/db/host is mapped to db.host,
+	// it is ended with / so /app does not load /application/db/host
+	Prefix string
+	// Key loads a YAML or JSON document stored under one key, it overrides Prefix
+	Key        string
+	ReaderType ReaderType
+
+	Timeout time.Duration
+	OnError func(error)
+
+	// ConfigOptions are applied to resolve the values, exp: OptionSecretKey, OptionENVAllowed
+	ConfigOptions []OptionFunc
+}
+
+// EtcdPrefix sets the key prefix to load configs from.
+func EtcdPrefix(prefix string) EtcdOption {
+	return func(o *EtcdOptions) {
+		o.Prefix = prefix
+	}
+}
+
+// EtcdKey sets the key of a document to load configs from, rt is ReaderTypeYAML or ReaderTypeJSON.
+func EtcdKey(key string, rt ReaderType) EtcdOption {
+	return func(o *EtcdOptions) {
+		o.Key = key
+		o.ReaderType = rt
+	}
+}
+
+// EtcdTimeout sets the timeout for loading configs from etcd.
+func EtcdTimeout(timeout time.Duration) EtcdOption {
+	return func(o *EtcdOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// EtcdConfigOptions sets the options to resolve the values, exp: the secret key of ENC[...] values.
+func EtcdConfigOptions(opts ...OptionFunc) EtcdOption {
+	return func(o *EtcdOptions) {
+		o.ConfigOptions = append(o.ConfigOptions, opts...)
+	}
+}
+
+// EtcdOnError sets the handler of watching errors, the last good config is kept on error.
+func EtcdOnError(fn func(error)) EtcdOption {
+	return func(o *EtcdOptions) {
+		o.OnError = fn
+	}
+}
+
+type etcdConfig struct {
+	*AdapterConfig
+	notifier
+
+	client  EtcdClient
+	options EtcdOptions
+
+	mu  sync.Mutex
+	raw map[string]any
+	// revision is the etcd revision of raw, older responses and events are ignored
+	revision int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewEtcdConfig loads configs from etcd and watches the changes until Close is called.
+func NewEtcdConfig(client EtcdClient, opts ...EtcdOption) (EtcdConfig, error) {
+	if client == nil {
+		return nil, ErrNilEtcdClient
+	}
+
+	p := &etcdConfig{client: client}
+	for _, opt := range opts {
+		opt(&p.options)
+	}
+	if p.options.Timeout <= 0 {
+		p.options.Timeout = DefaultEtcdTimeout
+	}
+	if len(p.options.Prefix) > 0 && !strings.HasSuffix(p.options.Prefix, "/") {
+		p.options.Prefix += "/"
+	}
+
+	rt := ReaderTypeYAML
+	if len(p.options.Key) > 0 {
+		if p.options.ReaderType != ReaderTypeJSON && p.options.ReaderType != ReaderTypeYAML {
+			return nil, ErrNotSupportedReaderType
+		}
+		rt = p.options.ReaderType
+	}
+	reader, err := NewReader(rt, "")
+	if err != nil {
+		return nil, err
+	}
+	p.AdapterConfig = &AdapterConfig{readerType: rt, reader: reader, configs: make(map[string]any)}
+	for _, opt := range p.options.ConfigOptions {
+		opt(p.AdapterConfig)
+	}
+
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	revision, err := p.load()
+	if err != nil {
+		p.cancel()
+		return nil, err
+	}
+
+	go p.watch(revision)
+
+	return p, nil
+}
+
+// Reload loads all configs from etcd and notifies the changes.
+func (p *etcdConfig) Reload() error {
+	_, err := p.load()
+	return err
+}
+
+// Close stops watching etcd.
+func (p *etcdConfig) Close() error {
+	p.cancel()
+	return nil
+}
+
+func (p *etcdConfig) load() (int64, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.options.Timeout)
+	defer cancel()
+
+	var (
+		resp *clientv3.GetResponse
+		err  error
+	)
+	if len(p.options.Key) > 0 {
+		resp, err = p.client.Get(ctx, p.options.Key)
+	} else {
+		resp, err = p.client.Get(ctx, p.options.Prefix, clientv3.WithPrefix())
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	raw := make(map[string]any)
+	for _, kv := range resp.Kvs {
+		if err = p.putValue(raw, string(kv.Key), kv.Value); err != nil {
+			return 0, err
+		}
+	}
+
+	revision := resp.Header.GetRevision()
+	p.mu.Lock()
+	if revision < p.revision {
+		// the events after revision are applied already
+		revision = p.revision
+		p.mu.Unlock()
+		return revision, nil
+	}
+	old, resolved, err := p.apply(raw, revision)
+	p.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	p.notify(changedKeys(old.configs, resolved.configs), old, resolved.copy())
+	return revision, nil
+}
+
+func (p *etcdConfig) watch(revision int64) {
+	for {
+		key, opts := p.options.Key, []clientv3.OpOption{clientv3.WithRev(revision + 1)}
+		if len(key) == 0 {
+			key, opts = p.options.Prefix, append(opts, clientv3.WithPrefix())
+		}
+
+		for resp := range p.client.Watch(p.ctx, key, opts...) {
+			if err := resp.Err(); err != nil {
+				p.onError(err)
+				break
+			}
+			if err := p.applyEvents(resp.Events); err != nil {
+				p.onError(err)
+			}
+			revision = resp.Header.GetRevision()
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		// the watch channel was closed or compacted, reload all configs and watch again
+		rev, err := p.load()
+		if err != nil {
+			p.onError(err)
+			continue
+		}
+		revision = rev
+	}
+}
+
+func (p *etcdConfig) applyEvents(events []*clientv3.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	raw, revision := DeepCopy(p.raw).(map[string]any), p.revision
+	var errs errcode.Errors
+	for _, ev := range events {
+		// the events before the revision of the latest load are in raw already
+		if ev.Kv.ModRevision != 0 && ev.Kv.ModRevision <= p.revision {
+			continue
+		}
+		revision = max(revision, ev.Kv.ModRevision)
+
+		switch ev.Type {
+		case clientv3.EventTypeDelete:
+			if len(p.options.Key) > 0 {
+				raw = make(map[string]any)
+				continue
+			}
+			deleteKeyValue(raw, p.keyPath(string(ev.Kv.Key)))
+		default:
+			// the broken value is skipped, the other events are still applied
+			if err := p.putValue(raw, string(ev.Kv.Key), ev.Kv.Value); err != nil {
+				errs = errs.Append(err)
+			}
+		}
+	}
+	old, resolved, err := p.apply(raw, revision)
+	p.mu.Unlock()
+	if err != nil {
+		return errs.Append(err).Errors()
+	}
+
+	p.notify(changedKeys(old.configs, resolved.configs), old, resolved.copy())
+	return errs.Errors()
+}
+
+// apply saves raw configs at revision, decrypts the ENC[...] values and resolves ${} expressions of them,
+// then swaps the configs, and returns the configs with the old and new values to notify the changes out of lock.
+// The last good config is kept if the values can not be resolved, and raw configs are resolved again by the next change.
+func (p *etcdConfig) apply(raw map[string]any, revision int64) (*AdapterConfig, *AdapterConfig, error) {
+	p.raw, p.revision = raw, revision
+
+	resolved := p.AdapterConfig.copy()
+	resolved.configs = DeepCopy(raw).(map[string]any)
+	resolved.secrets = nil
+	if _, err := resolved.decryptValues("", resolved.configs); err != nil {
+		return nil, nil, err
+	}
+	if err := resolved.interpolate(); err != nil {
+		return nil, nil, err
+	}
+
+	p.locker.Lock()
+	// keep the secret key loaded by the first encrypted value
+	p.secretKey = resolved.secretKey
+	p.locker.Unlock()
+	old := p.swap(resolved.configs, nil, resolved.secrets)
+	return old, resolved, nil
+}
+
+func (p *etcdConfig) putValue(raw map[string]any, key string, value []byte) error {
+	if len(p.options.Key) > 0 {
+		doc := make(map[string]any)
+		if err := p.reader.ParseData(value, &doc); err != nil {
+			return err
+		}
+		for k := range raw {
+			delete(raw, k)
+		}
+		for k, v := range doc {
+			raw[k] = v
+		}
+		return nil
+	}
+
+	path := p.keyPath(key)
+	if len(path) == 0 {
+		return nil
+	}
+
+	setMapValue(raw, path, parseValue(string(value)))
+	return nil
+}
+
+// keyPath maps the etcd key to config key path: /app/db/host with prefix /app to db.host
+func (p *etcdConfig) keyPath(key string) []string {
+	key = strings.Trim(strings.TrimPrefix(key, p.options.Prefix), "/")
+	if len(key) == 0 {
+		return nil
+	}
+	return strings.Split(key, "/")
+}
+
+func (p *etcdConfig) onError(err error) {
+	if p.options.OnError != nil {
+		p.options.OnError(err)
+	}
+}
+
+func setMapValue(m map[string]any, path []string, value any) {
+	for _, token := range path[:len(path)-1] {
+		next, ok := m[token].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[token] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func deleteKeyValue(m map[string]any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	next, ok := m[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+	deleteKeyValue(next, path[1:])
+	if len(next) == 0 {
+		delete(m, path[0])
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type fakeEtcd struct {
+	clientv3.KV
+	clientv3.Watcher
+
+	kvs      []*mvccpb.KeyValue
+	revision int64
+	events   chan clientv3.WatchResponse
+}
+
+func newFakeEtcd(kvs map[string]string) *fakeEtcd {
+	f := &fakeEtcd{events: make(chan clientv3.WatchResponse, 1)}
+	for k, v := range kvs {
+		f.kvs = append(f.kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+	}
+	return f
+}
+
+func (p *fakeEtcd) Get(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: p.revision}}
+	for _, kv := range p.kvs {
+		if strings.HasPrefix(string(kv.Key), key) {
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	return resp, nil
+}
+
+func (p *fakeEtcd) Watch(ctx context.Context, _ string, _ ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-p.events:
+				ch <- resp
+			}
+		}
+	}()
+	return ch
+}
+
+func (p *fakeEtcd) send(events ...*clientv3.Event) {
+	p.events <- clientv3.WatchResponse{Events: events}
+}
+
+func putEvent(key, value string) *clientv3.Event {
+	return &clientv3.Event{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value)}}
+}
+
+func deleteEvent(key string) *clientv3.Event {
+	return &clientv3.Event{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte(key)}}
+}
+
+func TestEtcdConfig_Prefix(t *testing.T) {
+	client := newFakeEtcd(map[string]string{
+		"/app/db/host":    "localhost",
+		"/app/db/port":    "3306",
+		"/app/db/dsn":     "${db.host}:3306",
+		"/app/log/level":  "info",
+		"/other/db/host":  "other",
+		"/application/db": "other",
+		"/app/feature/on": "true",
+	})
+
+	cfg, err := config.NewEtcdConfig(client, config.EtcdPrefix("/app"))
+	testutils.Ok(t, err)
+	defer cfg.Close()
+
+	testutils.Equals(t, "localhost", cfg.GetString("db.host"))
+	testutils.Equals(t, 3306, cfg.GetInt("db.port"))
+	testutils.Equals(t, "info", cfg.GetString("log.level"))
+	testutils.Assert(t, cfg.GetBoolean("feature.on"), "feature.on should be true")
+	testutils.Equals(t, []string{"db", "feature", "log"}, sortedKeys(cfg.GetKeys()))
+
+	changed := make(chan []string, 1)
+	cfg.Subscribe(func(keys []string) { changed <- keys })
+	levels := make(chan string, 1)
+	cfg.Watch("log", func(_, new config.Config) { levels <- new.GetString("log.level") })
+
+	client.send(putEvent("/app/log/level", "debug"), deleteEvent("/app/feature/on"))
+
+	select {
+	case keys := <-changed:
+		testutils.Equals(t, []string{"feature.on", "log.level"}, keys)
+		testutils.Equals(t, "debug", <-levels)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not updated")
+	}
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+	testutils.Assert(t, cfg.GetInterface("feature") == nil, "feature should be deleted")
+}
+
+func TestEtcdConfig_Key(t *testing.T) {
+	client := newFakeEtcd(map[string]string{
+		"/app/config": `{"db": {"host": "localhost"}}`,
+	})
+
+	errs := make(chan error, 1)
+	cfg, err := config.NewEtcdConfig(client,
+		config.EtcdKey("/app/config", config.ReaderTypeJSON),
+		config.EtcdOnError(func(err error) { errs <- err }))
+	testutils.Ok(t, err)
+	defer cfg.Close()
+
+	testutils.Equals(t, "localhost", cfg.GetString("db.host"))
+
+	// broken documents keep the last good config
+	client.send(putEvent("/app/config", `{"db": `))
+	select {
+	case err = <-errs:
+		testutils.NotOk(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("error was not reported")
+	}
+	testutils.Equals(t, "localhost", cfg.GetString("db.host"))
+
+	changed := make(chan struct{}, 1)
+	cfg.Watch("db.host", func(_, _ config.Config) { changed <- struct{}{} })
+	client.send(putEvent("/app/config", `{"db": {"host": "db.local"}}`))
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not updated")
+	}
+	testutils.Equals(t, "db.local", cfg.GetString("db.host"))
+}
+
+func TestEtcdConfig_NilClient(t *testing.T) {
+	_, err := config.NewEtcdConfig(nil)
+	testutils.ErrorEqual(t, config.ErrNilEtcdClient, err)
+}
+
+func TestEtcdConfig_Encrypted(t *testing.T) {
+	key := make([]byte, config.SecretKeySize)
+	password, err := config.EncryptValue(key, "p@ssword")
+	testutils.Ok(t, err)
+
+	client := newFakeEtcd(map[string]string{
+		"/app/db/password": password,
+		"/app/db/dsn":      "root:${db.password}@localhost",
+	})
+	cfg, err := config.NewEtcdConfig(client, config.EtcdPrefix("/app/"),
+		config.EtcdConfigOptions(config.OptionSecretKey(key)))
+	testutils.Ok(t, err)
+	defer cfg.Close()
+
+	testutils.Equals(t, "p@ssword", cfg.GetString("db.password"))
+	testutils.Equals(t, "root:p@ssword@localhost", cfg.GetString("db.dsn"))
+
+	changed := make(chan config.Changes, 1)
+	cfg.Watch("db", func(old, new config.Config) { changed <- config.Diff(old, new) })
+	password, err = config.EncryptValue(key, "new-p@ssword")
+	testutils.Ok(t, err)
+	client.send(putEvent("/app/db/password", password))
+
+	select {
+	case changes := <-changed:
+		testutils.Equals(t, "~ db.dsn: <hidden> -> <hidden>\n~ db.password: <hidden> -> <hidden>", changes.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not updated")
+	}
+	testutils.Equals(t, "new-p@ssword", cfg.GetString("db.password"))
+}
+
+func TestEtcdConfig_Revision(t *testing.T) {
+	client := newFakeEtcd(map[string]string{"/app/log/level": "info"})
+	client.revision = 10
+
+	cfg, err := config.NewEtcdConfig(client, config.EtcdPrefix("/app"))
+	testutils.Ok(t, err)
+	defer cfg.Close()
+
+	changed := make(chan []string, 1)
+	cfg.Subscribe(func(keys []string) { changed <- keys })
+
+	// events in the loaded revision are ignored
+	stale := putEvent("/app/log/level", "warn")
+	stale.Kv.ModRevision = 9
+	event := putEvent("/app/log/level", "debug")
+	event.Kv.ModRevision = 12
+	client.send(stale, event)
+
+	select {
+	case keys := <-changed:
+		testutils.Equals(t, []string{"log.level"}, keys)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not updated")
+	}
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+
+	// responses older than the applied events are ignored
+	testutils.Ok(t, cfg.Reload())
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+}
+
+func TestEtcdConfig_ApplyError(t *testing.T) {
+	client := newFakeEtcd(map[string]string{"/app/log/level": "info", "/app/db/user": "root"})
+
+	errs := make(chan error, 1)
+	cfg, err := config.NewEtcdConfig(client, config.EtcdPrefix("/app"), config.EtcdOnError(func(err error) { errs <- err }))
+	testutils.Ok(t, err)
+	defer cfg.Close()
+
+	changed := make(chan []string, 1)
+	cfg.Subscribe(func(keys []string) { changed <- keys })
+
+	// the last good config is kept if a value of the batch can not be resolved
+	client.send(putEvent("/app/log/level", "debug"), deleteEvent("/app/db/user"),
+		putEvent("/app/db/dsn", "${db.password:?must be set}"))
+	select {
+	case err := <-errs:
+		testutils.Assert(t, strings.Contains(err.Error(), "must be set"), "error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("error was not reported")
+	}
+	testutils.Equals(t, "info", cfg.GetString("log.level"))
+
+	// the other events of the failed batch are applied with the fixed value
+	client.send(putEvent("/app/db/dsn", "root@localhost"))
+	select {
+	case keys := <-changed:
+		testutils.Equals(t, []string{"db.dsn", "db.user", "log.level"}, sortedKeys(keys))
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not updated")
+	}
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+	testutils.Equals(t, "root@localhost", cfg.GetString("db.dsn"))
+	testutils.Assert(t, cfg.GetInterface("db.user") == nil, "db.user should be deleted")
+}
+
+func sortedKeys(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/types"
+)
+
+// Get returns the value of key as T, an error is returned if the key is not found or the value is not a T.
+// Besides the types unmarshaled by the reader, time.Duration, types.Duration and types.ByteSize
+// accept values like 30s, 1day and 10mb.
+//
+//	timeout, err := config.Get[time.Duration](c, "http.timeout")
+//	servers, err := config.Get[[]Server](c, "servers")
+func Get[T any](c Config, key string) (T, error) {
+	var v T
+
+	value := c.GetInterface(key)
+	if value == nil {
+		return v, errcode.Newf("%s: %s", key, ErrKeyNotFound)
+	}
+
+	if err := convertValue(c, key, value, &v); err != nil {
+		return v, errcode.Newf("%s: expected %s: %v", key, reflect.TypeOf(&v).Elem(), err)
+	}
+	return v, nil
+}
+
+// MustGet returns the value of key as T, or panics.
+func MustGet[T any](c Config, key string) T {
+	v, err := Get[T](c, key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Bind returns the value of key as T, and validates it with the validate tags of T,
+// the violations are returned in errcode.Errors as *ValidationError.
+//
+//	db, err := config.Bind[Database](c, "database")
+func Bind[T any](c Config, key string) (T, error) {
+	v, err := Get[T](c, key)
+	if err != nil {
+		return v, err
+	}
+
+	a := adapterOf(c)
+	errs := a.validateValue(key, reflect.TypeOf(&v).Elem(), DeepCopy(c.GetInterface(key)), &validateRules{}, nil)
+	return v, errs.Errors()
+}
+
+func convertValue(c Config, key string, value any, model any) error {
+	switch m := model.(type) {
+	case *time.Duration:
+		d, ok := toDuration(value)
+		if !ok {
+			return errcode.Newf("invalid duration %v", value)
+		}
+		*m = d
+	case *types.Duration:
+		d, ok := toDuration(value)
+		if !ok {
+			return errcode.Newf("invalid duration %v", value)
+		}
+		*m = types.Duration(d)
+	case *types.ByteSize:
+		size, err := types.ParseByteSize(fmt.Sprint(value))
+		if err != nil {
+			return err
+		}
+		*m = size
+	default:
+		rv := reflect.ValueOf(model).Elem()
+		if v := reflect.ValueOf(DeepCopy(value)); v.Type().AssignableTo(rv.Type()) {
+			rv.Set(v)
+			return nil
+		}
+		return c.Object(model, ObjOptionKey(key))
+	}
+	return nil
+}
+
+// adapterOf returns the AdapterConfig of c, or an AdapterConfig with the values of c.
+func adapterOf(c Config) *AdapterConfig {
+	if a, ok := c.(interface{ adapter() *AdapterConfig }); ok {
+		return a.adapter()
+	}
+	return &AdapterConfig{configs: configValues(c)}
+}
+
+func (p *AdapterConfig) adapter() *AdapterConfig {
+	return p
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/go-trellis/common/utils/types"
+)
+
+const genericYAML = `
+http:
+  address: "127.0.0.1:8080"
+  timeout: 30s
+  idle: 1day
+  max-body: 10mib
+  retries: 0
+db:
+  password: p@ssword
+servers:
+  - name: a
+    port: 80
+  - name: b
+    port: 0
+labels:
+  env: prod
+  zone: a
+`
+
+type genericServer struct {
+	Name string `yaml:"name" json:"name" validate:"required"`
+	Port int    `yaml:"port" json:"port" validate:"min=1"`
+}
+
+func TestGet(t *testing.T) {
+	for _, rt := range []config.ReaderType{config.ReaderTypeYAML, config.ReaderTypeJSON} {
+		c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, genericYAML))
+		testutils.Ok(t, err)
+		if rt == config.ReaderTypeJSON {
+			bs, err := config.NewJSONReader().Dump(config.MustGet[map[string]any](c, "http"))
+			testutils.Ok(t, err)
+			c, err = config.NewConfigOptions(config.OptionString(rt, `{"http": `+string(bs)+`, "servers": [{"name": "a", "port": 80}]}`))
+			testutils.Ok(t, err)
+		}
+
+		timeout, err := config.Get[time.Duration](c, "http.timeout")
+		testutils.Ok(t, err)
+		testutils.Equals(t, 30*time.Second, timeout)
+		testutils.Equals(t, types.Duration(24*time.Hour), config.MustGet[types.Duration](c, "http.idle"))
+		testutils.Equals(t, types.ByteSize(10<<20), config.MustGet[types.ByteSize](c, "http.max-body"))
+		testutils.Equals(t, types.HostPort{Host: "127.0.0.1", Port: "8080"}, config.MustGet[types.HostPort](c, "http.address"))
+
+		// zero value is not a missing key
+		retries, err := config.Get[int](c, "http.retries")
+		testutils.Ok(t, err)
+		testutils.Equals(t, 0, retries)
+
+		servers, err := config.Get[[]genericServer](c, "servers")
+		testutils.Ok(t, err)
+		testutils.Equals(t, genericServer{Name: "a", Port: 80}, servers[0])
+	}
+
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, genericYAML))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, types.Secret("p@ssword"), config.MustGet[types.Secret](c, "db.password"))
+	testutils.Equals(t, map[string]string{"env": "prod", "zone": "a"}, config.MustGet[map[string]string](c, "labels"))
+
+	_, err = config.Get[int](c, "http.missing")
+	testutils.ErrorEqual(t, errcode.Newf("http.missing: %s", config.ErrKeyNotFound), err)
+
+	_, err = config.Get[time.Duration](c, "db.password")
+	testutils.ErrorEqual(t, errcode.New("db.password: expected time.Duration: invalid duration p@ssword"), err)
+
+	_, err = config.Get[int](c, "servers")
+	testutils.NotOk(t, err)
+
+	defer func() {
+		testutils.Assert(t, recover() != nil, "MustGet should panic")
+	}()
+	config.MustGet[bool](c, "labels.missing")
+}
+
+func TestBind(t *testing.T) {
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, genericYAML))
+	testutils.Ok(t, err)
+
+	servers, err := config.Bind[[]genericServer](c, "servers")
+	testutils.NotOk(t, err)
+	testutils.Equals(t, 2, len(servers))
+	testutils.Equals(t, "servers[1].port: must be at least 1", err.Error())
+
+	type httpConfig struct {
+		Timeout time.Duration  `yaml:"timeout" validate:"max=1m"`
+		MaxBody types.ByteSize `yaml:"max-body" validate:"max=1mib"`
+	}
+	_, err = config.Bind[httpConfig](c, "http")
+	testutils.Equals(t, "http.max-body: must be at most 1mib", err.Error())
+
+	testutils.Ok(t, c.SetKeyValue("http.max-body", "512kb"))
+	h, err := config.Bind[httpConfig](c, "http")
+	testutils.Ok(t, err)
+	testutils.Equals(t, types.ByteSize(512000), h.MaxBody)
+}
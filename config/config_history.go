@@ -0,0 +1,150 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultHistorySize is the number of records kept by a config.
+const DefaultHistorySize = 100
+
+var packagePath = reflect.TypeOf(AdapterConfig{}).PkgPath()
+
+// Record is a change of key made by SetKeyValue or SetKeyValueBy.
+type Record struct {
+	Version uint64
+	Key     string
+	Old     any
+	New     any
+	// By is who or what changed the key, SetKeyValue records the calling function.
+	By   string
+	Time time.Time
+}
+
+// Snapshot is a copy of config values at a version.
+type Snapshot struct {
+	Config
+	Version uint64
+	Time    time.Time
+}
+
+// OptionHistorySize option function to set the number of records kept, negative size disables the history.
+func OptionHistorySize(size int) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.historySize = size
+	}
+}
+
+// SetKeyValueBy sets key's value into config, and records that it is changed by who or what.
+func (p *AdapterConfig) SetKeyValueBy(key string, value any, by string) error {
+	if len(key) == 0 {
+		return ErrInvalidKey
+	}
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	old, _ := p.getKeyValue(key)
+	old = DeepCopy(old)
+	if err := p.setKeyValue(key, value); err != nil {
+		return err
+	}
+
+	secret := hasSecret(p.secrets, key)
+	if secret {
+		p.resetSecret(key, value)
+	}
+	p.record(key, old, DeepCopy(value), by, secret)
+	return nil
+}
+
+// resetSecret keeps the new value of a secret key as a secret, it is encrypted for Dump if it's a string.
+// The secrets in the old value of key are removed.
+func (p *AdapterConfig) resetSecret(key string, value any) {
+	if !isSecretKey(p.secrets, key) {
+		p.unmarkSecrets(key)
+		return
+	}
+	_, ok := p.secrets[key]
+	p.unmarkSecrets(key)
+	if !ok {
+		// key is in a secret value
+		return
+	}
+
+	raw := ""
+	if s, ok := value.(string); ok && len(p.secretKey) > 0 {
+		raw, _ = EncryptValue(p.secretKey, s)
+	}
+	p.markSecret(key, raw)
+}
+
+// Snapshot returns a copy of config values with the current version.
+func (p *AdapterConfig) Snapshot() *Snapshot {
+	c := p.copy()
+	return &Snapshot{Config: c, Version: c.version, Time: time.Now()}
+}
+
+// History returns the records of changes, the oldest first.
+func (p *AdapterConfig) History() []Record {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	return append([]Record(nil), p.history...)
+}
+
+func (p *AdapterConfig) record(key string, old, value any, by string, secret bool) {
+	p.version++
+
+	size := p.historySize
+	if size == 0 {
+		size = DefaultHistorySize
+	}
+	if size < 0 {
+		return
+	}
+
+	p.history = append(p.history, Record{
+		Version: p.version,
+		Key:     key,
+		Old:     redactKey(secret, old),
+		New:     redactKey(secret, value),
+		By:      by,
+		Time:    time.Now(),
+	})
+	if len(p.history) > size {
+		p.history = append([]Record(nil), p.history[len(p.history)-size:]...)
+	}
+}
+
+// caller returns the first function calling into this package, exp: main.main
+func caller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") {
+			return frame.Function
+		}
+		if !more {
+			return ""
+		}
+	}
+}
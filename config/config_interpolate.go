@@ -0,0 +1,435 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/files"
+)
+
+var (
+	refNameReg  = regexp.MustCompile(`^[0-9a-zA-Z_.\-]+$`)
+	funcCallReg = regexp.MustCompile(`(?s)^([a-zA-Z_][0-9a-zA-Z_]*)\((.*)\)$`)
+)
+
+// interpolateFunc is a function can be called in ${...}, exp: ${file(/run/secrets/db)}
+type interpolateFunc func(c *AdapterConfig, args ...string) (any, error)
+
+var interpolateFuncs = map[string]interpolateFunc{
+	"env":          interpolateEnv,
+	"file":         interpolateFile,
+	"base64":       interpolateBase64,
+	"base64decode": interpolateBase64Decode,
+}
+
+// interpolator resolves the ${...} expressions of config values in place,
+// every key is resolved once, and the keys being resolved are kept to detect cycles.
+type interpolator struct {
+	config     *AdapterConfig
+	resolving  []string
+	resolved   map[string]bool
+	unresolved errcode.Errors
+	// secret is set if the value being expanded references a secret
+	secret bool
+}
+
+// interpolate resolves the ${...} expressions of all config values:
+//
+//	${key}            environment variable if allowed, or value of the config key,
+//	                  the type of the value is kept if the expression is the whole value
+//	${key:-default}   default if the key is unset or empty
+//	${key:?message}   fails with message if the key is unset or empty
+//	${file(path)}     content of the file, the path is relative to the config file
+//	${env(NAME)}      environment variable NAME
+//	${base64(text)}   base64 encoded text, base64decode(text) decodes it
+//	$${               literal ${
+//
+// References to unset keys are kept as they are, exp: ${DB_HOST} and x-${DB_HOST},
+// except that the values of nested keys are resolved to nil, like the earlier versions.
+// They are reported all together with OptionStrictReferences.
+func (p *AdapterConfig) interpolate() error {
+	in := &interpolator{config: p, resolved: make(map[string]bool)}
+	if err := in.resolveMap("", true, p.configs); err != nil {
+		return err
+	}
+	return in.unresolved.Errors()
+}
+
+func (p *interpolator) resolveKey(key string) (any, error) {
+	value, err := p.config.getKeyValue(key)
+	if err != nil || p.resolved[key] {
+		return value, nil
+	}
+
+	for i, k := range p.resolving {
+		if k == key {
+			return nil, errcode.Newf("%s: %s", ErrCyclicReference,
+				strings.Join(append(p.resolving[i:], key), " -> "))
+		}
+	}
+	p.resolving = append(p.resolving, key)
+	defer func() { p.resolving = p.resolving[:len(p.resolving)-1] }()
+
+	newValue, err := p.resolve(key, true, value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := value.(string); ok {
+		if err = p.config.setKeyValue(key, newValue); err != nil {
+			return nil, err
+		}
+	}
+	p.resolved[key] = true
+	return newValue, nil
+}
+
+// resolve resolves the value of path, values of addressable paths are resolved by keys for references.
+func (p *interpolator) resolve(path string, addressable bool, value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		secret := p.secret
+		p.secret = false
+		newValue, err := p.expand(path, v)
+		// a decrypted value keeps its encrypted raw value
+		if _, ok := p.config.secrets[path]; err == nil && p.secret && !ok {
+			p.config.markSecret(path, v)
+		}
+		p.secret = secret
+		return newValue, err
+	case map[string]any:
+		return v, p.resolveMap(path, addressable, v)
+	case Options:
+		return v, p.resolveMap(path, addressable, v)
+	case map[any]any:
+		for k, item := range v {
+			newItem, err := p.resolve(joinKey(path, fmt.Sprint(k)), false, item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = newItem
+		}
+	case []any:
+		for i, item := range v {
+			newItem, err := p.resolve(fmt.Sprintf("%s[%d]", path, i), false, item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = newItem
+		}
+	}
+	return value, nil
+}
+
+func (p *interpolator) resolveMap(path string, addressable bool, values map[string]any) error {
+	for _, k := range sortedKeys(values) {
+		key := joinKey(path, k)
+		if addressable && !strings.Contains(k, ".") {
+			if _, err := p.resolveKey(key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		newValue, err := p.resolve(key, false, values[k])
+		if err != nil {
+			return err
+		}
+		values[k] = newValue
+	}
+	return nil
+}
+
+// expand evaluates all ${...} expressions in s.
+func (p *interpolator) expand(path, s string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	sb := strings.Builder{}
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+
+		if start > 0 && s[start-1] == '$' {
+			sb.WriteString(s[:start-1])
+			sb.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+
+		end := closingBrace(s, start+2)
+		if end < 0 {
+			return nil, errcode.Newf("%s: %s: %q", path, ErrInvalidExpression, s[start:])
+		}
+
+		value, err := p.eval(path, s[start+2:end])
+		if err != nil {
+			return nil, err
+		}
+
+		if start == 0 && end == len(s)-1 && sb.Len() == 0 {
+			if value == nil && !strings.ContainsAny(path, ".[") {
+				// the unresolved value of a top level key is kept as it is
+				return s, nil
+			}
+			return value, nil
+		}
+
+		sb.WriteString(s[:start])
+		if value == nil {
+			// the unresolved expression in a text is kept as it is
+			sb.WriteString(s[start : end+1])
+		} else {
+			sb.WriteString(interpolateString(value))
+		}
+		s = s[end+1:]
+	}
+}
+
+// eval evaluates the expression inside ${}.
+func (p *interpolator) eval(path, expr string) (any, error) {
+	expr = strings.TrimSpace(expr)
+
+	if matched := funcCallReg.FindStringSubmatch(expr); matched != nil {
+		return p.call(path, matched[1], matched[2])
+	}
+
+	name, op, arg := expr, "", ""
+	if i := strings.Index(expr, ":"); i >= 0 {
+		name, op, arg = expr[:i], expr[i:min(i+2, len(expr))], expr[min(i+2, len(expr)):]
+	}
+	if !refNameReg.MatchString(name) || (op != "" && op != ":-" && op != ":?") {
+		return nil, errcode.Newf("%s: %s: %q", path, ErrInvalidExpression, "${"+expr+"}")
+	}
+
+	value, err := p.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if hasSecret(p.config.secrets, name) {
+		p.secret = true
+	}
+	if value != nil && value != "" {
+		return value, nil
+	}
+
+	switch op {
+	case ":-":
+		return p.expand(path, arg)
+	case ":?":
+		if arg == "" {
+			arg = "is required"
+		}
+		return nil, errcode.Newf("%s: %s: %s", path, name, arg)
+	}
+	if value == nil && p.config.strictReferences {
+		p.unresolved = p.unresolved.Append(&ValidationError{
+			Key:     path,
+			Message: fmt.Sprintf("%s: ${%s}", ErrUnresolvedReference, name),
+		})
+	}
+	return value, nil
+}
+
+func (p *interpolator) lookup(name string) (any, error) {
+	c := p.config
+	if c.EnvAllowed && (c.EnvPrefix == "" || strings.HasPrefix(name, c.EnvPrefix)) {
+		if env := os.Getenv(name); env != "" {
+			return env, nil
+		}
+	}
+	return p.resolveKey(name)
+}
+
+func (p *interpolator) call(path, name, args string) (any, error) {
+	fn, ok := interpolateFuncs[name]
+	if !ok {
+		return nil, errcode.Newf("%s: unknown function %s()", path, name)
+	}
+
+	var values []string
+	for _, arg := range splitArgs(args) {
+		value, err := p.arg(path, arg)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	value, err := fn(p.config, values...)
+	if err != nil {
+		return nil, errcode.Newf("%s: %s(): %v", path, name, err)
+	}
+	return value, nil
+}
+
+// arg evaluates a function argument: a quoted string, a function call or a plain text, all may contain ${...}
+func (p *interpolator) arg(path, arg string) (string, error) {
+	arg = strings.TrimSpace(arg)
+
+	switch {
+	case len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"':
+		s, err := strconv.Unquote(arg)
+		if err != nil {
+			return "", errcode.Newf("%s: %s: %s", path, ErrInvalidExpression, arg)
+		}
+		arg = s
+	case len(arg) >= 2 && arg[0] == '\'' && arg[len(arg)-1] == '\'':
+		arg = arg[1 : len(arg)-1]
+	case funcCallReg.MatchString(arg):
+		value, err := p.eval(path, arg)
+		if err != nil {
+			return "", err
+		}
+		return interpolateString(value), nil
+	}
+
+	value, err := p.expand(path, arg)
+	if err != nil {
+		return "", err
+	}
+	return interpolateString(value), nil
+}
+
+// closingBrace returns the index of the brace closing the expression starting at start, or -1.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && quote == '"' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs splits function arguments by the commas out of quotes, parentheses and braces.
+func splitArgs(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	var (
+		args  []string
+		depth int
+		last  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && quote == '"' {
+					i++
+				}
+			}
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(args, s[last:])
+}
+
+func interpolateString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func checkArgs(args []string, n int) error {
+	if len(args) != n {
+		return errcode.Newf("expects %d argument(s), got %d", n, len(args))
+	}
+	return nil
+}
+
+func interpolateEnv(_ *AdapterConfig, args ...string) (any, error) {
+	if err := checkArgs(args, 1); err != nil {
+		return nil, err
+	}
+	return os.Getenv(args[0]), nil
+}
+
+func interpolateFile(c *AdapterConfig, args ...string) (any, error) {
+	if err := checkArgs(args, 1); err != nil {
+		return nil, err
+	}
+
+	filename := args[0]
+	if !filepath.IsAbs(filename) && c.ConfigFile != "" {
+		filename = filepath.Join(filepath.Dir(c.ConfigFile), filename)
+	}
+	data, _, err := files.Read(filename)
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func interpolateBase64(_ *AdapterConfig, args ...string) (any, error) {
+	if err := checkArgs(args, 1); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(args[0])), nil
+}
+
+func interpolateBase64Decode(_ *AdapterConfig, args ...string) (any, error) {
+	if err := checkArgs(args, 1); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
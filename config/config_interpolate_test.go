@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestInterpolate(t *testing.T) {
+	tmpDir := t.TempDir()
+	testutils.Ok(t, os.WriteFile(filepath.Join(tmpDir, "db.password"), []byte("p@ssword\n"), 0600))
+
+	t.Setenv("TINTERP_HOST", "db.local")
+	t.Setenv("TINTERP_EMPTY", "")
+
+	filename := filepath.Join(tmpDir, "app.yaml")
+	err := os.WriteFile(filename, []byte(`
+db:
+  host: ${TINTERP_HOST}
+  port: 3306
+  addr: "${db.host}:${db.port}"
+  dsn: "mysql://${db.user}@${db.addr}/${db.name:-app}"
+  user: ${TINTERP_EMPTY:-root}
+  password: ${file(db.password)}
+  auth: ${base64("${db.user}:${db.password}")}
+  plain: ${base64decode(${db.auth})}
+  home: ${env(TINTERP_HOST)}
+  ports: ${ports}
+  literal: "$${db.host}"
+ports: [80, 443]
+`), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfigOptions(config.OptionFile(filename), config.OptionENVAllowed(), config.OptionENVPrefix("TINTERP"))
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "db.local", cfg.GetString("db.host"))
+	testutils.Equals(t, "db.local:3306", cfg.GetString("db.addr"))
+	testutils.Equals(t, "mysql://root@db.local:3306/app", cfg.GetString("db.dsn"))
+	testutils.Equals(t, "p@ssword", cfg.GetString("db.password"))
+	testutils.Equals(t, "cm9vdDpwQHNzd29yZA==", cfg.GetString("db.auth"))
+	testutils.Equals(t, "root:p@ssword", cfg.GetString("db.plain"))
+	testutils.Equals(t, "db.local", cfg.GetString("db.home"))
+	testutils.Equals(t, []int{80, 443}, cfg.GetIntList("db.ports"))
+	testutils.Equals(t, "${db.host}", cfg.GetString("db.literal"))
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		yaml    string
+		message string
+	}{
+		{name: "required", yaml: "a: ${missing:?must be set}", message: "a: missing: must be set"},
+		{name: "required default", yaml: "a: ${missing:?}", message: "a: missing: is required"},
+		{name: "cycle", yaml: "a: ${b}\nb: x${c}\nc: ${a}", message: "cyclic reference of config values: a -> b -> c -> a"},
+		{name: "self", yaml: "a:\n  b: ${a}", message: "cyclic reference of config values: a -> a.b -> a"},
+		{name: "unclosed", yaml: `a: "${b"`, message: "invalid interpolation expression"},
+		{name: "invalid", yaml: `a: "${b:+c}"`, message: "invalid interpolation expression"},
+		{name: "unknown function", yaml: `a: ${upper(b)}`, message: "a: unknown function upper()"},
+		{name: "arguments", yaml: `a: ${env(A, B)}`, message: "a: env(): expects 1 argument(s), got 2"},
+		{name: "file", yaml: `a: ${file(/not/exist)}`, message: "a: file():"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, c.yaml))
+			testutils.NotOk(t, err)
+			testutils.Assert(t, strings.Contains(err.Error(), c.message), "error %q should contain %q", err, c.message)
+		})
+	}
+}
+
+func TestInterpolate_StrictReferences(t *testing.T) {
+	yaml := "a: ${missing}\nb: x-${b2}\nc: ${missing:-ok}"
+
+	c, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, yaml+"\nd:\n  e: ${missing}\n  f: x-${missing}"))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "${missing}", c.GetInterface("a"))
+	testutils.Equals(t, "x-${b2}", c.GetString("b"))
+	testutils.Equals(t, "ok", c.GetString("c"))
+	testutils.Equals(t, nil, c.GetInterface("d.e"))
+	testutils.Equals(t, "x-${missing}", c.GetString("d.f"))
+
+	_, err = config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, yaml), config.OptionStrictReferences())
+	testutils.NotOk(t, err)
+	testutils.Equals(t, "a: unresolved reference: ${missing};b: unresolved reference: ${b2}", err.Error())
+}
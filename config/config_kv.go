@@ -18,79 +18,18 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package config
 
 import (
-	"os"
-	"reflect"
 	"strings"
 
-	"github.com/go-trellis/common/errors/errcode"
-	"github.com/go-trellis/common/utils/types"
+	"gopkg.in/yaml.v3"
 )
 
-func (p *AdapterConfig) copyDollarSymbol(maps *map[string]any) error {
-	for mapK, mapV := range *maps {
-		value := p.checkValue(mapV)
-		if value != nil {
-			(*maps)[mapK] = value
-		}
-	}
-	return nil
-}
-
-// checkValue checks if the value is a valid type and replaces it with an environment variable if it matches the pattern.
-func (p *AdapterConfig) checkValue(value any) any {
-	if value == nil {
-		return nil
-	}
-
-	t := reflect.TypeOf(value)
-	switch t.Kind() {
-	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float64, reflect.Float32:
-		return value
-	case reflect.Bool:
-		return value
-	case reflect.String:
-		s, ok := value.(string)
-		if !ok {
-			return value
-		}
-		_, matched := types.FindStringSubmatchMap(s, includeReg)
-		if !matched {
-			return value
-		}
-
-		pKey := s[2 : len(s)-1]
-
-		if p.EnvAllowed && (p.EnvPrefix == "" || strings.HasPrefix(pKey, p.EnvPrefix)) {
-			if env := os.Getenv(pKey); env != "" {
-				return env
-			}
-		}
-		v, err := p.getKeyValue(pKey)
-		if err != nil {
-			return nil
-		}
-
-		return v
-	case reflect.Slice:
-		vs := value.([]any)
-		for i, v := range vs {
-			newV := p.checkValue(v)
-			vs[i] = newV
-		}
-		return vs
-	case reflect.Map:
-		vs, ok := value.(map[string]any)
-		if !ok {
-			return value
-		}
-		for k, v := range vs {
-			newV := p.checkValue(v)
-			vs[k] = newV
-		}
-		return vs
-	default:
-		panic(errcode.Newf("not suppered type: %+v", t))
+// parseValue parses a plain text value as YAML, exp: 3306, true, [a, b], or returns the text.
+func parseValue(s string) any {
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v == nil {
+		return s
 	}
+	return v
 }
 
 // getKeyValue retrieves the value for a given key from the configuration.
@@ -0,0 +1,301 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Layer names
+const (
+	LayerNameDefaults = "defaults"
+	LayerNameEnv      = "env"
+	LayerNameFlags    = "flags"
+	LayerNameRuntime  = "runtime"
+)
+
+// Layer is a source of configs stacked by NewLayeredConfig.
+type Layer interface {
+	// Name returns the name reported by Provenance
+	Name() string
+	// Load returns the values of the layer, base holds the values of all lower layers,
+	// the ${...} expressions are resolved once all layers are merged
+	Load(base Config) (map[string]any, error)
+}
+
+// LayeredConfig is a config stacked by layers, values of later layers override earlier ones.
+type LayeredConfig interface {
+	Config
+	// Provenance returns the name of the layer supplying the value of key,
+	// for a map key, it returns the highest layer supplying any of its children
+	Provenance(key string) (string, bool)
+	// Layers returns the names of all layers from lowest to highest precedence
+	Layers() []string
+}
+
+type layerFunc struct {
+	name string
+	load func(base Config) (map[string]any, error)
+	// opts are the options of LayerDefaults and LayerFile, applied to resolve the merged values
+	opts []OptionFunc
+}
+
+func (p *layerFunc) Name() string {
+	return p.name
+}
+
+func (p *layerFunc) Load(base Config) (map[string]any, error) {
+	return p.load(base)
+}
+
+// NewLayer returns a layer with name and load function.
+func NewLayer(name string, load func(base Config) (map[string]any, error)) Layer {
+	return &layerFunc{name: name, load: load}
+}
+
+// LayerConfig returns a layer with all values of c.
+func LayerConfig(name string, c Config) Layer {
+	return NewLayer(name, func(Config) (map[string]any, error) {
+		return configValues(c), nil
+	})
+}
+
+// LayerDefaults returns the defaults layer, exp: LayerDefaults(OptionString(ReaderTypeYAML, embedded)).
+func LayerDefaults(opts ...OptionFunc) Layer {
+	return &layerFunc{name: LayerNameDefaults, load: rawLoader(opts), opts: opts}
+}
+
+// LayerFile returns a layer of config file named "file:{filename}".
+// The options, exp: OptionSecretKey, are applied to resolve the merged values,
+// and ${file(...)} paths are relative to the last config file.
+func LayerFile(filename string, opts ...OptionFunc) Layer {
+	opts = append([]OptionFunc{OptionFile(filename)}, opts...)
+	return &layerFunc{name: "file:" + filename, load: rawLoader(opts), opts: opts}
+}
+
+// rawLoader returns the function loading the raw values by options, they are resolved by the layered config.
+func rawLoader(opts []OptionFunc) func(Config) (map[string]any, error) {
+	return func(Config) (map[string]any, error) {
+		c := &AdapterConfig{}
+		if err := c.parse(opts...); err != nil {
+			return nil, err
+		}
+		return c.configs, nil
+	}
+}
+
+// LayerEnv returns the layer of environment variables with prefix, APP_DB_HOST is mapped to db.host.
+// Variables matching keys of lower layers are mapped to the keys, exp: APP_LOG_MAX_AGE to log.max_age.
+// If prefix is empty, only variables matching keys of lower layers are loaded.
+func LayerEnv(prefix string) Layer {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	return NewLayer(LayerNameEnv, func(base Config) (map[string]any, error) {
+		known := make(map[string]string)
+		for key := range flattenConfig(base) {
+			known[envName(key)] = key
+		}
+
+		values := make(map[string]any)
+		for _, kv := range os.Environ() {
+			name, value, ok := strings.Cut(kv, "=")
+			if !ok || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+
+			key, ok := known[name]
+			if !ok {
+				if prefix == "" || name == "" {
+					continue
+				}
+				key = strings.ReplaceAll(strings.ToLower(name), "_", ".")
+			}
+			setMapValue(values, strings.Split(key, "."), parseValue(value))
+		}
+		return values, nil
+	})
+}
+
+// LayerFlags returns the layer of flags set in fs, the flag name is the key, exp: -db.host.
+func LayerFlags(fs *flag.FlagSet) Layer {
+	return NewLayer(LayerNameFlags, func(Config) (map[string]any, error) {
+		values := make(map[string]any)
+		fs.Visit(func(f *flag.Flag) {
+			setMapValue(values, strings.Split(f.Name, "."), parseValue(f.Value.String()))
+		})
+		return values, nil
+	})
+}
+
+type layeredConfig struct {
+	*AdapterConfig
+
+	mu         sync.RWMutex
+	layers     []string
+	provenance map[string]string
+}
+
+// NewLayeredConfig stacks layers from lowest to highest precedence,
+// exp: NewLayeredConfig(LayerDefaults(...), LayerFile("app.yaml"), LayerEnv("APP"), LayerFlags(fs))
+func NewLayeredConfig(layers ...Layer) (LayeredConfig, error) {
+	p := &layeredConfig{
+		AdapterConfig: &AdapterConfig{
+			readerType: ReaderTypeYAML,
+			reader:     NewYAMLReader(),
+			configs:    make(map[string]any),
+		},
+		provenance: make(map[string]string),
+	}
+
+	options := &AdapterConfig{}
+	for _, layer := range layers {
+		if l, ok := layer.(*layerFunc); ok {
+			for _, opt := range l.opts {
+				opt(options)
+			}
+		}
+
+		values, err := layer.Load(p.AdapterConfig.copy())
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range flattenValuesOf(values) {
+			p.setProvenance(key, layer.Name())
+		}
+		p.mergeConfigs(&p.configs, values)
+		p.layers = append(p.layers, layer.Name())
+	}
+
+	// the references are resolved once on the merged values, so the higher layers override the referenced keys
+	p.ConfigFile, p.EnvPrefix, p.EnvAllowed = options.ConfigFile, options.EnvPrefix, options.EnvAllowed
+	p.strictReferences = options.strictReferences
+	p.secretKey, p.secretKeyFile, p.secretKeyEnv = options.secretKey, options.secretKeyFile, options.secretKeyEnv
+	if _, err := p.decryptValues("", p.configs); err != nil {
+		return nil, err
+	}
+	if err := p.interpolate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Provenance returns the name of the layer supplying the value of key.
+func (p *layeredConfig) Provenance(key string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if name, ok := p.provenance[key]; ok {
+		return name, true
+	}
+
+	// key is a map, find the highest layer of its children
+	level, name := -1, ""
+	for k, n := range p.provenance {
+		if !strings.HasPrefix(k, key+".") {
+			continue
+		}
+		if l := p.layerLevel(n); l > level {
+			level, name = l, n
+		}
+	}
+	return name, level >= 0
+}
+
+// Layers returns the names of all layers.
+func (p *layeredConfig) Layers() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.layers...)
+}
+
+// SetKeyValue sets key's value, the provenance of key is runtime.
+func (p *layeredConfig) SetKeyValue(key string, value any) error {
+	return p.SetKeyValueBy(key, value, caller())
+}
+
+// SetKeyValueBy sets key's value and records who or what changed it, the provenance of key is runtime.
+func (p *layeredConfig) SetKeyValueBy(key string, value any, by string) error {
+	if err := p.AdapterConfig.SetKeyValueBy(key, value, by); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k := range flattenValuesOf(map[string]any{key: value}) {
+		p.setProvenance(k, LayerNameRuntime)
+	}
+	return nil
+}
+
+// setProvenance records the layer of the leaf key, and drops the records replaced by it.
+func (p *layeredConfig) setProvenance(key, name string) {
+	for k := range p.provenance {
+		if strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			delete(p.provenance, k)
+		}
+	}
+	p.provenance[key] = name
+}
+
+func (p *layeredConfig) layerLevel(name string) int {
+	if name == LayerNameRuntime {
+		return len(p.layers)
+	}
+	for i := len(p.layers) - 1; i >= 0; i-- {
+		if p.layers[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// configValues returns a deep copy of all values in c.
+func configValues(c Config) map[string]any {
+	if ac, ok := c.(*AdapterConfig); ok {
+		return ac.copy().configs
+	}
+	values := make(map[string]any)
+	for _, key := range c.GetKeys() {
+		values[key] = DeepCopy(c.GetInterface(key))
+	}
+	return values
+}
+
+func flattenConfig(c Config) map[string]any {
+	return flattenValuesOf(configValues(c))
+}
+
+func flattenValuesOf(m map[string]any) map[string]any {
+	values := make(map[string]any)
+	if len(m) > 0 {
+		flattenValues("", m, values)
+	}
+	return values
+}
+
+// envName returns the environment variable name of key, exp: log.max-age to LOG_MAX_AGE.
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
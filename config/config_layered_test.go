@@ -0,0 +1,137 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+const layeredDefaults = `
+db:
+  host: localhost
+  port: 3306
+log:
+  level: info
+  max_age: 7d
+`
+
+func TestLayeredConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "app.yml")
+	err := os.WriteFile(file, []byte("db:\n  host: db.local\n  user: app\n"), 0644)
+	testutils.Ok(t, err)
+
+	t.Setenv("TLAYER_DB_USER", "env-user")
+	t.Setenv("TLAYER_LOG_MAX_AGE", "30d")
+	t.Setenv("TLAYER_FEATURE_NEW", "true")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db.port", "", "db port")
+	fs.String("log.level", "", "log level")
+	testutils.Ok(t, fs.Parse([]string{"-db.port", "3307"}))
+
+	cfg, err := config.NewLayeredConfig(
+		config.LayerDefaults(config.OptionString(config.ReaderTypeYAML, layeredDefaults)),
+		config.LayerFile(file),
+		config.LayerEnv("TLAYER"),
+		config.LayerFlags(fs),
+	)
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, []string{"defaults", "file:" + file, "env", "flags"}, cfg.Layers())
+
+	testutils.Equals(t, "db.local", cfg.GetString("db.host"))
+	testutils.Equals(t, "env-user", cfg.GetString("db.user"))
+	testutils.Equals(t, 3307, cfg.GetInt("db.port"))
+	testutils.Equals(t, "info", cfg.GetString("log.level"))
+	testutils.Equals(t, "30d", cfg.GetString("log.max_age"))
+	testutils.Assert(t, cfg.GetBoolean("feature.new"), "feature.new should be true")
+
+	tests := map[string]string{
+		"db.host":     "file:" + file,
+		"db.user":     "env",
+		"db.port":     "flags",
+		"log.level":   "defaults",
+		"log.max_age": "env",
+		"db":          "flags",
+	}
+	for key, layer := range tests {
+		name, ok := cfg.Provenance(key)
+		testutils.Assert(t, ok, "provenance of %s should be found", key)
+		testutils.Equals(t, layer, name, "provenance of %s", key)
+	}
+
+	_, ok := cfg.Provenance("not.exist")
+	testutils.Assert(t, !ok, "provenance of not.exist should not be found")
+
+	testutils.Ok(t, cfg.SetKeyValue("log.level", "debug"))
+	name, _ := cfg.Provenance("log.level")
+	testutils.Equals(t, config.LayerNameRuntime, name)
+}
+
+func TestLayeredConfig_FileNotFound(t *testing.T) {
+	_, err := config.NewLayeredConfig(config.LayerFile("not_exist.yml"))
+	testutils.NotOk(t, err)
+}
+
+func TestLayeredConfig_References(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "app.yml")
+	err := os.WriteFile(file, []byte("db:\n  host: db.local\n"), 0644)
+	testutils.Ok(t, err)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db.port", "", "db port")
+	testutils.Ok(t, fs.Parse([]string{"-db.port", "3307"}))
+
+	cfg, err := config.NewLayeredConfig(
+		config.LayerDefaults(config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost
+  port: 3306
+  dsn: ${db.host}:${db.port}
+`)),
+		config.LayerFile(file),
+		config.LayerFlags(fs),
+	)
+	testutils.Ok(t, err)
+
+	// references are resolved with the values of the highest layers
+	testutils.Equals(t, "db.local:3307", cfg.GetString("db.dsn"))
+	name, _ := cfg.Provenance("db.dsn")
+	testutils.Equals(t, config.LayerNameDefaults, name)
+
+	key := make([]byte, config.SecretKeySize)
+	password, err := config.EncryptValue(key, "p@ssword")
+	testutils.Ok(t, err)
+	err = os.WriteFile(file, []byte("db:\n  password: "+password+"\n"), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err = config.NewLayeredConfig(
+		config.LayerDefaults(config.OptionString(config.ReaderTypeYAML, "db:\n  dsn: root:${db.password}@localhost\n")),
+		config.LayerFile(file, config.OptionSecretKey(key)),
+	)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "root:p@ssword@localhost", cfg.GetString("db.dsn"))
+}
@@ -0,0 +1,444 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/files"
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/types"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultSecretKeyEnv is the environment variable of the base64 encoded secret key,
+	// it is used if no secret key option is set.
+	DefaultSecretKeyEnv = "TRELLIS_CONFIG_KEY"
+	// SecretKeySize is the size of the AES-256 secret key.
+	SecretKeySize = 32
+
+	encryptedPrefix = "ENC[aes256-gcm,"
+	encryptedSuffix = "]"
+)
+
+// OptionSecretKey option function to set the key for decrypting ENC[aes256-gcm,...] values.
+func OptionSecretKey(key []byte) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.secretKey = key
+	}
+}
+
+// OptionSecretKeyFile option function to read the base64 encoded secret key from file.
+func OptionSecretKeyFile(filename string) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.secretKeyFile = filename
+	}
+}
+
+// OptionSecretKeyEnv option function to read the base64 encoded secret key from environment variable.
+func OptionSecretKeyEnv(name string) OptionFunc {
+	return func(c *AdapterConfig) {
+		c.secretKeyEnv = name
+	}
+}
+
+// GenerateSecretKey returns a random base64 encoded secret key.
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, SecretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseSecretKey decodes the base64 encoded secret key.
+func ParseSecretKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != SecretKeySize {
+		return nil, ErrInvalidSecretKey
+	}
+	return key, nil
+}
+
+// IsEncrypted reports whether s is an encrypted value: ENC[aes256-gcm,data:...,iv:...,tag:...]
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encryptedPrefix) && strings.HasSuffix(s, encryptedSuffix)
+}
+
+// EncryptValue encrypts plaintext to ENC[aes256-gcm,data:...,iv:...,tag:...] with AES-256-GCM.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.StdEncoding
+	return encryptedPrefix + "data:" + enc.EncodeToString(data) +
+		",iv:" + enc.EncodeToString(iv) +
+		",tag:" + enc.EncodeToString(tag) + encryptedSuffix, nil
+}
+
+// DecryptValue decrypts the value encrypted by EncryptValue.
+func DecryptValue(key []byte, s string) (string, error) {
+	if !IsEncrypted(s) {
+		return "", ErrInvalidEncryptedValue
+	}
+
+	fields := make(map[string][]byte)
+	for _, field := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, encryptedPrefix), encryptedSuffix), ",") {
+		name, value, ok := strings.Cut(field, ":")
+		if !ok {
+			return "", ErrInvalidEncryptedValue
+		}
+		bs, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", ErrInvalidEncryptedValue
+		}
+		fields[name] = bs
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(fields["iv"]) != gcm.NonceSize() {
+		return "", ErrInvalidEncryptedValue
+	}
+
+	plaintext, err := gcm.Open(nil, fields["iv"], append(fields["data"], fields["tag"]...), nil)
+	if err != nil {
+		return "", errcode.Newf("failed to decrypt value: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// EncryptFileKeys encrypts the string values of keys in a YAML or JSON file, and leaves the rest readable.
+// Comments and order of YAML files are kept, JSON files are rewritten with indent.
+func EncryptFileKeys(filename string, key []byte, keys ...string) error {
+	data, _, err := files.Read(filename)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	switch fileToReaderType(filename) {
+	case ReaderTypeYAML:
+		data, err = encryptYAMLKeys(data, key, keys)
+	case ReaderTypeJSON:
+		data, err = encryptJSONKeys(data, key, keys)
+	default:
+		return ErrNotSupportedReaderType
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, fi.Mode())
+}
+
+func encryptYAMLKeys(data, key []byte, keys []string) ([]byte, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, ErrNotMap
+	}
+
+	for _, k := range keys {
+		node := doc.Content[0]
+		for _, token := range strings.Split(k, ".") {
+			if node.Kind != yaml.MappingNode {
+				return nil, errcode.Newf("%s: %s", ErrNotMap, k)
+			}
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					next = node.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return nil, errcode.Newf("%s: %s", ErrInvalidKey, k)
+			}
+			node = next
+		}
+
+		if node.Kind != yaml.ScalarNode {
+			return nil, errcode.Newf("value of %s is not a string", k)
+		}
+		if IsEncrypted(node.Value) {
+			continue
+		}
+		v, err := EncryptValue(key, node.Value)
+		if err != nil {
+			return nil, err
+		}
+		node.Value, node.Tag, node.Style = v, "!!str", 0
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encryptJSONKeys(data, key []byte, keys []string) ([]byte, error) {
+	c := &AdapterConfig{configs: make(map[string]any)}
+	if err := ParseJSONData(data, &c.configs); err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		v, err := c.getKeyValue(k)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, errcode.Newf("value of %s is not a string", k)
+		}
+		if IsEncrypted(s) {
+			continue
+		}
+		if s, err = EncryptValue(key, s); err != nil {
+			return nil, err
+		}
+		if err = c.setKeyValue(k, s); err != nil {
+			return nil, err
+		}
+	}
+
+	bs, err := json.MarshalIndent(c.configs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(bs, '\n'), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != SecretKeySize {
+		return nil, ErrInvalidSecretKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getSecretKey returns the secret key by options, or from DefaultSecretKeyEnv.
+func (p *AdapterConfig) getSecretKey() ([]byte, error) {
+	switch {
+	case len(p.secretKey) > 0:
+		return p.secretKey, nil
+	case p.secretKeyFile != "":
+		data, _, err := files.Read(p.secretKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ParseSecretKey(string(data))
+	}
+
+	name := p.secretKeyEnv
+	if name == "" {
+		name = DefaultSecretKeyEnv
+	}
+	s := os.Getenv(name)
+	if s == "" {
+		return nil, ErrSecretKeyNotFound
+	}
+	return ParseSecretKey(s)
+}
+
+// decryptValues decrypts all encrypted values, the secret key is loaded only if there are encrypted values.
+func (p *AdapterConfig) decryptValues(prefix string, value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !IsEncrypted(v) {
+			return v, nil
+		}
+		if p.secretKey == nil {
+			key, err := p.getSecretKey()
+			if err != nil {
+				return nil, err
+			}
+			p.secretKey = key
+		}
+		plaintext, err := DecryptValue(p.secretKey, v)
+		if err != nil {
+			return nil, errcode.Newf("%s: %v", prefix, err)
+		}
+		p.markSecret(prefix, v)
+		return plaintext, nil
+	case map[string]any:
+		for k, item := range v {
+			newItem, err := p.decryptValues(joinKey(prefix, k), item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = newItem
+		}
+	case Options:
+		return p.decryptValues(prefix, map[string]any(v))
+	case map[any]any:
+		for k, item := range v {
+			newItem, err := p.decryptValues(joinKey(prefix, fmt.Sprint(k)), item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = newItem
+		}
+	case []any:
+		for i, item := range v {
+			newItem, err := p.decryptValues(fmt.Sprintf("%s[%d]", prefix, i), item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = newItem
+		}
+	}
+	return value, nil
+}
+
+// markSecret marks the value of key as a secret, raw is the value dumped instead of the plaintext:
+// the encrypted value, or the ${...} expression referencing secrets; empty raw is dumped as types.Hidden.
+func (p *AdapterConfig) markSecret(key, raw string) {
+	if p.secrets == nil {
+		p.secrets = make(map[string]string)
+	}
+	p.secrets[key] = raw
+}
+
+// unmarkSecrets removes the secrets of key and its children.
+func (p *AdapterConfig) unmarkSecrets(key string) {
+	for k := range p.secrets {
+		if k == key || isChildKey(key, k) {
+			delete(p.secrets, k)
+		}
+	}
+}
+
+// secretKeys returns a copy of the keys of secrets with their raw values.
+func (p *AdapterConfig) secretKeys() map[string]string {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	return maps.Clone(p.secrets)
+}
+
+// secretKeysOf returns the keys of secrets of c, a config not loaded by this package has no secrets.
+func secretKeysOf(c Config) map[string]string {
+	switch v := c.(type) {
+	case *Snapshot:
+		return secretKeysOf(v.Config)
+	case interface{ secretKeys() map[string]string }:
+		return v.secretKeys()
+	}
+	return nil
+}
+
+// isSecretKey reports whether the value of key is a secret or in a secret.
+func isSecretKey(secrets map[string]string, key string) bool {
+	for k := range secrets {
+		if k == key || isChildKey(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSecret reports whether the value of key is a secret, in a secret or contains secrets.
+func hasSecret(secrets map[string]string, key string) bool {
+	for k := range secrets {
+		if k == key || isChildKey(k, key) || isChildKey(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// isChildKey reports whether key is in the value of parent, exp: db.password and servers[0].host in db and servers.
+func isChildKey(parent, key string) bool {
+	return len(key) > len(parent) && strings.HasPrefix(key, parent) &&
+		(key[len(parent)] == '.' || key[len(parent)] == '[')
+}
+
+// Redact returns a copy of all values of c, the decrypted values and values referencing them are types.Hidden.
+func Redact(c Config) map[string]any {
+	secrets := make(map[string]string)
+	for k := range secretKeysOf(c) {
+		secrets[k] = ""
+	}
+	return dumpSecrets(secrets, "", configValues(c)).(map[string]any)
+}
+
+// dumpSecrets replaces the secrets in value by their raw values, or types.Hidden.
+func dumpSecrets(secrets map[string]string, prefix string, value any) any {
+	if raw, ok := secrets[prefix]; ok && prefix != "" {
+		if raw == "" {
+			return types.Hidden
+		}
+		return raw
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = dumpSecrets(secrets, joinKey(prefix, k), item)
+		}
+	case Options:
+		dumpSecrets(secrets, prefix, map[string]any(v))
+	case map[any]any:
+		for k, item := range v {
+			v[k] = dumpSecrets(secrets, joinKey(prefix, fmt.Sprint(k)), item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = dumpSecrets(secrets, fmt.Sprintf("%s[%d]", prefix, i), item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/go-trellis/common/utils/types"
+)
+
+func TestEncryptValue(t *testing.T) {
+	encoded, err := config.GenerateSecretKey()
+	testutils.Ok(t, err)
+	key, err := config.ParseSecretKey(encoded)
+	testutils.Ok(t, err)
+
+	v, err := config.EncryptValue(key, "p@ssword")
+	testutils.Ok(t, err)
+	testutils.Assert(t, config.IsEncrypted(v), "value should be encrypted: %s", v)
+
+	plaintext, err := config.DecryptValue(key, v)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "p@ssword", plaintext)
+
+	otherKey := make([]byte, config.SecretKeySize)
+	_, err = config.DecryptValue(otherKey, v)
+	testutils.NotOk(t, err)
+
+	_, err = config.DecryptValue(key, "ENC[aes256-gcm,data:***]")
+	testutils.ErrorEqual(t, config.ErrInvalidEncryptedValue, err)
+
+	_, err = config.ParseSecretKey("c2hvcnQ=")
+	testutils.ErrorEqual(t, config.ErrInvalidSecretKey, err)
+}
+
+func TestEncryptFileKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	encoded, err := config.GenerateSecretKey()
+	testutils.Ok(t, err)
+	key, _ := config.ParseSecretKey(encoded)
+
+	keyFile := filepath.Join(tmpDir, "config.key")
+	testutils.Ok(t, os.WriteFile(keyFile, []byte(encoded+"\n"), 0600))
+
+	yamlFile := filepath.Join(tmpDir, "app.yml")
+	err = os.WriteFile(yamlFile, []byte(`# database settings
+database:
+  host: localhost # the host
+  password: p@ssword
+`), 0644)
+	testutils.Ok(t, err)
+
+	testutils.Ok(t, config.EncryptFileKeys(yamlFile, key, "database.password"))
+	// encrypted values are not encrypted again
+	testutils.Ok(t, config.EncryptFileKeys(yamlFile, key, "database.password"))
+	testutils.NotOk(t, config.EncryptFileKeys(yamlFile, key, "database.user"))
+
+	data, err := os.ReadFile(yamlFile)
+	testutils.Ok(t, err)
+	testutils.Assert(t, strings.Contains(string(data), "# database settings"), "comments should be kept:\n%s", data)
+	testutils.Assert(t, strings.Contains(string(data), "host: localhost # the host"), "host should be readable:\n%s", data)
+	testutils.Assert(t, !strings.Contains(string(data), "p@ssword"), "password should be encrypted:\n%s", data)
+
+	cfg, err := config.NewConfigOptions(config.OptionFile(yamlFile), config.OptionSecretKeyFile(keyFile))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "p@ssword", cfg.GetString("database.password"))
+
+	var db struct {
+		Password types.Secret `yaml:"password"`
+	}
+	testutils.Ok(t, cfg.Object(&db, config.ObjOptionKey("database")))
+	testutils.Equals(t, types.Secret("p@ssword"), db.Password)
+
+	t.Setenv(config.DefaultSecretKeyEnv, "")
+	_, err = config.NewConfig(yamlFile)
+	testutils.ErrorEqual(t, config.ErrSecretKeyNotFound, err)
+
+	t.Setenv("TEST_CONFIG_KEY", encoded)
+	cfg, err = config.NewConfigOptions(config.OptionFile(yamlFile), config.OptionSecretKeyEnv("TEST_CONFIG_KEY"))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "p@ssword", cfg.GetString("database.password"))
+
+	jsonFile := filepath.Join(tmpDir, "app.json")
+	err = os.WriteFile(jsonFile, []byte(`{"database": {"host": "localhost", "password": "p@ssword"}}`), 0644)
+	testutils.Ok(t, err)
+	testutils.Ok(t, config.EncryptFileKeys(jsonFile, key, "database.password"))
+
+	cfg, err = config.NewConfigOptions(config.OptionFile(jsonFile), config.OptionSecretKey(key))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "localhost", cfg.GetString("database.host"))
+	testutils.Equals(t, "p@ssword", cfg.GetString("database.password"))
+}
+
+func TestDump_Encrypted(t *testing.T) {
+	key := make([]byte, config.SecretKeySize)
+	password, err := config.EncryptValue(key, "p@ssword")
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfigOptions(config.OptionSecretKey(key), config.OptionString(config.ReaderTypeYAML, `
+db:
+  host: localhost
+  password: `+password+`
+  dsn: root:${db.password}@${db.host}
+`))
+	testutils.Ok(t, err)
+
+	for _, c := range []config.Config{cfg, cfg.Copy(), cfg.Snapshot()} {
+		data, err := c.Dump()
+		testutils.Ok(t, err)
+		testutils.Assert(t, !strings.Contains(string(data), "p@ssword"), "password should be encrypted:\n%s", data)
+		testutils.Assert(t, strings.Contains(string(data), password), "password should be dumped as is:\n%s", data)
+		testutils.Assert(t, strings.Contains(string(data), "root:${db.password}@${db.host}"), "dsn should be dumped as is:\n%s", data)
+	}
+
+	// the new value of an encrypted key is encrypted by the same key
+	testutils.Ok(t, cfg.SetKeyValue("db.password", "new-p@ssword"))
+	data, err := cfg.Dump()
+	testutils.Ok(t, err)
+	testutils.Assert(t, !strings.Contains(string(data), "p@ssword"), "password should be encrypted:\n%s", data)
+
+	dumped, err := config.NewConfigOptions(config.OptionSecretKey(key), config.OptionString(config.ReaderTypeYAML, string(data)))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "new-p@ssword", dumped.GetString("db.password"))
+	testutils.Equals(t, "root:p@ssword@localhost", cfg.GetString("db.dsn"))
+}
@@ -29,4 +29,14 @@ var (
 	ErrIncludeCircularRef     = errcode.New("circular reference detected in include files")
 	ErrIncludeFileNotFound    = errcode.New("include file not found")
 	ErrInvalidIncludeValue    = errcode.New("invalid include value, must be string or array of strings")
+	ErrNotWatchable           = errcode.New("config is not loaded from a file, can not be watched")
+	ErrNilEtcdClient          = errcode.New("etcd client is nil")
+	ErrInvalidSchema          = errcode.New("invalid schema, must be a struct or *JSONSchema")
+	ErrInvalidSecretKey       = errcode.New("invalid secret key, must be 32 bytes")
+	ErrSecretKeyNotFound      = errcode.New("secret key not found for encrypted values")
+	ErrInvalidEncryptedValue  = errcode.New("invalid encrypted value")
+	ErrCyclicReference        = errcode.New("cyclic reference of config values")
+	ErrInvalidExpression      = errcode.New("invalid interpolation expression")
+	ErrKeyNotFound            = errcode.New("key not found")
+	ErrUnresolvedReference    = errcode.New("unresolved reference")
 )
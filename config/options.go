@@ -26,14 +26,9 @@ func (p *Options) ToConfig(rts ...ReaderType) Config {
 	if len(rts) > 0 {
 		rt = rts[0]
 	}
-	c := &AdapterConfig{readerType: rt, configs: *p}
-	switch c.readerType {
-	case ReaderTypeJSON:
-		c.reader = NewJSONReader()
-	case ReaderTypeYAML:
-		c.reader = NewYAMLReader()
-	default:
+	reader, err := newConfigReader(rt, "")
+	if err != nil {
 		return nil
 	}
-	return c
+	return &AdapterConfig{readerType: rt, reader: reader, configs: *p}
 }
@@ -17,6 +17,10 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 
 package config
 
+import (
+	"gopkg.in/yaml.v3"
+)
+
 // ReaderType define reader type
 type ReaderType int
 
@@ -29,6 +33,12 @@ const (
 	ReaderTypeYAML
 	// ReaderTypeXML xml reader type
 	ReaderTypeXML
+	// ReaderTypeTOML toml reader type
+	ReaderTypeTOML
+	// ReaderTypeDotEnv .env reader type
+	ReaderTypeDotEnv
+	// ReaderTypeHCL hcl reader type
+	ReaderTypeHCL
 )
 
 // Reader reader repo
@@ -65,11 +75,46 @@ func NewReader(rt ReaderType, filename string) (Reader, error) {
 		return NewXMLReader(ReaderOptionFilename(filename)), nil
 	case ReaderTypeYAML:
 		return NewYAMLReader(ReaderOptionFilename(filename)), nil
+	case ReaderTypeTOML:
+		return NewTOMLReader(ReaderOptionFilename(filename)), nil
+	case ReaderTypeDotEnv:
+		return NewDotEnvReader(ReaderOptionFilename(filename)), nil
+	case ReaderTypeHCL:
+		return NewHCLReader(ReaderOptionFilename(filename)), nil
 	default:
 		return nil, ErrNotSupportedReaderType
 	}
 }
 
+// newConfigReader returns the reader of config values,
+// xml is not supported: encoding/xml can not unmarshal into map[string]any.
+func newConfigReader(rt ReaderType, filename string) (Reader, error) {
+	if rt == ReaderTypeXML {
+		return nil, ErrNotSupportedReaderType
+	}
+	return NewReader(rt, filename)
+}
+
+// decodeMap sets values into model, which is *map[string]any or a pointer of struct with yaml tags.
+func decodeMap(values map[string]any, model any) error {
+	if m, ok := model.(*map[string]any); ok {
+		if *m == nil {
+			*m = values
+			return nil
+		}
+		for k, v := range values {
+			(*m)[k] = v
+		}
+		return nil
+	}
+
+	bs, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(bs, model)
+}
+
 /*
 SPACE (\u0020)
 NO-BREAK SPACE (\u00A0)
@@ -0,0 +1,190 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-trellis/common/errors/errcode"
+	"gopkg.in/yaml.v3"
+)
+
+type defDotEnvReader struct {
+	opts ReaderOptions
+}
+
+// NewDotEnvReader creates a new .env reader with the given options.
+func NewDotEnvReader(opts ...ReaderOptionFunc) Reader {
+	r := &defDotEnvReader{}
+	for _, o := range opts {
+		o(&r.opts)
+	}
+	return r
+}
+
+func (p *defDotEnvReader) Read(model any) error {
+	data, err := ReadFile(p.opts.filename)
+	if err != nil {
+		return err
+	}
+	return ParseDotEnvData(data, model)
+}
+
+// Dump dumps values as KEY=VALUE lines, keys of nested maps are joined with "_", exp: db.host to DB_HOST.
+// The conversion is one-way: ParseData keeps DB_HOST as a flat key, because "_" is a part of plain variable names,
+// so read the dumped values by the flat keys, or by LayerEnv which maps DB_HOST to the known key db.host.
+func (*defDotEnvReader) Dump(v any) ([]byte, error) {
+	values, ok := v.(map[string]any)
+	if !ok {
+		values = make(map[string]any)
+		bs, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(bs, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	flat := flattenValuesOf(values)
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	for _, k := range keys {
+		name := k
+		if strings.Contains(k, ".") {
+			name = envName(k)
+		}
+		buf.WriteString(name + "=" + quoteDotEnvValue(flat[k]) + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (*defDotEnvReader) ParseData(data []byte, model any) error {
+	return ParseDotEnvData(data, model)
+}
+
+// ParseDotEnvData parses .env data into a given model:
+// KEY=VALUE lines, # comments, optional export prefix, 'single' and "double" quoted values,
+// and #include=a.env,b.toml lines of the included files.
+func ParseDotEnvData(data []byte, model any) error {
+	values := make(map[string]any)
+	var includes []any
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if files, ok := strings.CutPrefix(text, "#include="); ok {
+			v, err := unquoteDotEnvValue(strings.TrimSpace(files))
+			if err != nil {
+				return errcode.Newf("invalid .env line %d: %v", line, err)
+			}
+			for _, file := range strings.Split(v, ",") {
+				if file = strings.TrimSpace(file); file != "" {
+					includes = append(includes, file)
+				}
+			}
+			continue
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return errcode.Newf("invalid .env line %d: %s", line, text)
+		}
+
+		v, err := unquoteDotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return errcode.Newf("invalid .env line %d: %v", line, err)
+		}
+		values[key] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(includes) > 0 {
+		values["#include"] = includes
+	}
+
+	return decodeMap(values, model)
+}
+
+func unquoteDotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '"':
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value: %s", value)
+		}
+		return strconv.Unquote(value[:end+1])
+	case '\'':
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value: %s", value)
+		}
+		return value[1:end], nil
+	}
+
+	// remove inline comments: KEY=VALUE # comment
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+func quoteDotEnvValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		s = v
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		s = strings.Join(items, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if strings.ContainsAny(s, " \t\n\"'#\\$") {
+		return strconv.Quote(s)
+	}
+	return s
+}
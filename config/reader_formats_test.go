@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestMixedFormatIncludes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tmpDir, "app.json"), []byte(`{"app": {"name": "trellis"}}`), 0644)
+	testutils.Ok(t, err)
+
+	err = os.WriteFile(filepath.Join(tmpDir, "secrets.env"), []byte(`
+#include=app.json
+# database credentials
+export DB_USER=app
+DB_PASSWORD="p@ss \"word\""
+DB_NAME='trellis' # inline
+`), 0644)
+	testutils.Ok(t, err)
+
+	err = os.WriteFile(filepath.Join(tmpDir, "log.hcl"), []byte(`
+log {
+  level = "debug"
+  outputs = ["stdout", "file"]
+}
+service "web" {
+  port = 8080
+}
+`), 0644)
+	testutils.Ok(t, err)
+
+	mainFile := filepath.Join(tmpDir, "main.toml")
+	err = os.WriteFile(mainFile, []byte(`"#include" = ["secrets.env", "log.hcl"]
+
+[database]
+host = "localhost"
+port = 3306
+user = "${DB_USER}"
+`), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfig(mainFile)
+	testutils.Ok(t, err)
+
+	testutils.Equals(t, "localhost", cfg.GetString("database.host"))
+	testutils.Equals(t, 3306, cfg.GetInt("database.port"))
+	testutils.Equals(t, "app", cfg.GetString("database.user"))
+	testutils.Equals(t, `p@ss "word"`, cfg.GetString("DB_PASSWORD"))
+	testutils.Equals(t, "trellis", cfg.GetString("DB_NAME"))
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+	testutils.Equals(t, []string{"stdout", "file"}, cfg.GetStringList("log.outputs"))
+	testutils.Equals(t, 8080, cfg.GetInt("service.web.port"))
+	testutils.Equals(t, "trellis", cfg.GetString("app.name"))
+
+	var db struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	testutils.Ok(t, cfg.Object(&db, config.ObjOptionKey("database")))
+	testutils.Equals(t, "localhost", db.Host)
+	testutils.Equals(t, 3306, db.Port)
+
+	bs, err := cfg.Dump()
+	testutils.Ok(t, err)
+	dumped, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeTOML, string(bs)))
+	testutils.Ok(t, err)
+	testutils.Equals(t, "debug", dumped.GetString("log.level"))
+}
+
+func TestDotEnvReader(t *testing.T) {
+	r, err := config.NewReader(config.ReaderTypeDotEnv, "")
+	testutils.Ok(t, err)
+
+	bs, err := r.Dump(map[string]any{
+		"db":   map[string]any{"host": "localhost", "password": "a b#c"},
+		"PORT": 8080,
+	})
+	testutils.Ok(t, err)
+	testutils.Equals(t, "PORT=8080\nDB_HOST=localhost\nDB_PASSWORD=\"a b#c\"\n", string(bs))
+
+	values := map[string]any{}
+	testutils.Ok(t, r.ParseData(bs, &values))
+	// the dumped nested keys are read back as flat keys
+	testutils.Equals(t, map[string]any{"PORT": "8080", "DB_HOST": "localhost", "DB_PASSWORD": "a b#c"}, values)
+
+	values = map[string]any{}
+	testutils.Ok(t, r.ParseData([]byte("#include=a.env, b.toml\n#include=\"c.hcl\"\n# comment\nKEY=1\n"), &values))
+	testutils.Equals(t, map[string]any{"#include": []any{"a.env", "b.toml", "c.hcl"}, "KEY": "1"}, values)
+
+	testutils.NotOk(t, r.ParseData([]byte("INVALID"), &values))
+	testutils.NotOk(t, r.ParseData([]byte(`KEY="unterminated`), &values))
+}
+
+func TestSuffixReader_Formats(t *testing.T) {
+	for _, name := range []string{"a.toml", ".env", "a.env", "a.hcl"} {
+		r, err := config.NewSuffixReader(config.ReaderOptionFilename(name))
+		testutils.Ok(t, err)
+		testutils.Assert(t, r != nil, "reader of %s should not be nil", name)
+	}
+}
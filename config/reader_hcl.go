@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"github.com/go-trellis/common/utils/json"
+	"github.com/hashicorp/hcl"
+)
+
+type defHCLReader struct {
+	opts ReaderOptions
+}
+
+// NewHCLReader creates a new HCL reader with the given options,
+// configs are dumped in JSON syntax, which is valid HCL.
+func NewHCLReader(opts ...ReaderOptionFunc) Reader {
+	r := &defHCLReader{}
+	for _, o := range opts {
+		o(&r.opts)
+	}
+	return r
+}
+
+func (p *defHCLReader) Read(model any) error {
+	data, err := ReadFile(p.opts.filename)
+	if err != nil {
+		return err
+	}
+	return ParseHCLData(data, model)
+}
+
+func (*defHCLReader) Dump(v any) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (*defHCLReader) ParseData(data []byte, model any) error {
+	return ParseHCLData(data, model)
+}
+
+// ParseHCLData parses HCL data into a given model,
+// blocks are decoded as maps: db { host = "localhost" } to db.host.
+func ParseHCLData(data []byte, model any) error {
+	values := make(map[string]any)
+	if err := hcl.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	return decodeMap(normalizeHCL(values).(map[string]any), model)
+}
+
+// normalizeHCL merges the list of maps decoded from HCL blocks into one map.
+func normalizeHCL(value any) any {
+	switch v := value.(type) {
+	case []map[string]any:
+		merged := make(map[string]any)
+		for _, m := range v {
+			mergeValues(merged, normalizeHCL(m).(map[string]any))
+		}
+		return merged
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeHCL(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeHCL(item)
+		}
+		return v
+	}
+	return value
+}
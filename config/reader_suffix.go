@@ -29,7 +29,7 @@ type defSuffixReader struct {
 }
 
 // NewSuffixReader return a suffix reader
-// supported: .json, .xml, .yaml, .yml
+// supported: .json, .xml, .yaml, .yml, .toml, .env, .hcl
 func NewSuffixReader(opts ...ReaderOptionFunc) (reader Reader, err error) {
 	r := &defSuffixReader{}
 
@@ -61,17 +61,11 @@ func (p *defSuffixReader) ParseData(data []byte, model any) error {
 }
 
 func fileToReader(filename string) (Reader, error) {
-	switch {
-	case strings.HasSuffix(filename, ".json"):
-		return NewJSONReader(ReaderOptionFilename(filename)), nil
-	case strings.HasSuffix(filename, ".xml"):
-		return NewXMLReader(ReaderOptionFilename(filename)), nil
-	case strings.HasSuffix(filename, ".yml"),
-		strings.HasSuffix(filename, ".yaml"):
-		return NewYAMLReader(ReaderOptionFilename(filename)), nil
-	default:
+	rt := fileToReaderType(filename)
+	if rt == ReaderTypeSuffix {
 		return nil, ErrUnknownSuffixes
 	}
+	return NewReader(rt, filename)
 }
 
 func fileToReaderType(name string) ReaderType {
@@ -83,6 +77,12 @@ func fileToReaderType(name string) ReaderType {
 	case strings.HasSuffix(name, ".yml"),
 		strings.HasSuffix(name, ".yaml"):
 		return ReaderTypeYAML
+	case strings.HasSuffix(name, ".toml"):
+		return ReaderTypeTOML
+	case strings.HasSuffix(name, ".env"):
+		return ReaderTypeDotEnv
+	case strings.HasSuffix(name, ".hcl"):
+		return ReaderTypeHCL
 	default:
 		return ReaderTypeSuffix
 	}
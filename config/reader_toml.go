@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"github.com/pelletier/go-toml/v2"
+)
+
+type defTOMLReader struct {
+	opts ReaderOptions
+}
+
+// NewTOMLReader creates a new TOML reader with the given options.
+func NewTOMLReader(opts ...ReaderOptionFunc) Reader {
+	r := &defTOMLReader{}
+	for _, o := range opts {
+		o(&r.opts)
+	}
+	return r
+}
+
+func (p *defTOMLReader) Read(model any) error {
+	data, err := ReadFile(p.opts.filename)
+	if err != nil {
+		return err
+	}
+	return ParseTOMLData(data, model)
+}
+
+func (*defTOMLReader) Dump(v any) ([]byte, error) {
+	return toml.Marshal(v)
+}
+
+func (*defTOMLReader) ParseData(data []byte, model any) error {
+	return ParseTOMLData(data, model)
+}
+
+// ParseTOMLData parses TOML data into a given model.
+func ParseTOMLData(data []byte, model any) error {
+	return toml.Unmarshal(data, model)
+}
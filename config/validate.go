@@ -0,0 +1,651 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateTag is the struct tag of validation rules:
+// required, min=1, max=10, oneof=a b c, pattern=^[a-z]+$ (pattern must be the last rule).
+// min and max limit numbers, durations (exp: min=1s), byte sizes (exp: max=1mb),
+// and the length of strings, lists and maps.
+const ValidateTag = "validate"
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	typesDuration = reflect.TypeOf(types.Duration(0))
+	byteSizeType  = reflect.TypeOf(types.ByteSize(0))
+	unmarshalers  = []reflect.Type{
+		reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem(),
+		reflect.TypeOf((*interface{ UnmarshalYAML(func(any) error) error })(nil)).Elem(),
+		reflect.TypeOf((*interface{ UnmarshalJSON([]byte) error })(nil)).Elem(),
+		reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem(),
+	}
+)
+
+// ValidationError is a violation of the config schema.
+type ValidationError struct {
+	// Key is the full key path of the value, exp: db.hosts[0].port
+	Key     string
+	Message string
+}
+
+func (p *ValidationError) Error() string {
+	if p.Key == "" {
+		return p.Message
+	}
+	return p.Key + ": " + p.Message
+}
+
+// Validate checks all values by schema, and returns errcode.Errors of every *ValidationError.
+// schema is a struct or a pointer to struct with validate tags, or a *JSONSchema.
+func (p *AdapterConfig) Validate(schema any) error {
+	values := p.copy().configs
+
+	var errs errcode.Errors
+	switch s := schema.(type) {
+	case *JSONSchema:
+		errs = s.validate("", values, errs)
+	default:
+		t := reflect.TypeOf(schema)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return ErrInvalidSchema
+		}
+		errs = p.validateStruct("", t, values, errs)
+	}
+	return errs.Errors()
+}
+
+type validateRules struct {
+	required bool
+	min, max string
+	oneOf    []string
+	pattern  *regexp.Regexp
+}
+
+func parseValidateRules(tag string) (*validateRules, error) {
+	rules := &validateRules{}
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			rules.required = true
+		case "min":
+			rules.min = value
+		case "max":
+			rules.max = value
+		case "oneof":
+			rules.oneOf = strings.Fields(value)
+		case "pattern":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, err
+			}
+			rules.pattern = re
+		default:
+			return nil, errcode.Newf("unknown validate rule: %s", name)
+		}
+	}
+	return rules, nil
+}
+
+func (p *AdapterConfig) validateStruct(prefix string, t reflect.Type, value any, errs errcode.Errors) errcode.Errors {
+	values, ok := toStringMap(value)
+	if !ok {
+		return errs.Append(&ValidationError{Key: prefix, Message: fmt.Sprintf("expected map, got %T", value)})
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := p.fieldKey(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				errs = p.validateStruct(prefix, ft, values, errs)
+			}
+			continue
+		}
+
+		key := joinKey(prefix, name)
+		rules, err := parseValidateRules(field.Tag.Get(ValidateTag))
+		if err != nil {
+			errs = errs.Append(&ValidationError{Key: key, Message: err.Error()})
+			continue
+		}
+
+		v, exists := lookupKey(values, name)
+		if !exists || v == nil {
+			if rules.required {
+				errs = errs.Append(&ValidationError{Key: key, Message: "is required"})
+			}
+			continue
+		}
+		errs = p.validateValue(key, field.Type, v, rules, errs)
+	}
+	return errs
+}
+
+func (p *AdapterConfig) validateValue(key string, t reflect.Type, value any, rules *validateRules, errs errcode.Errors) errcode.Errors {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	appendErr := func(format string, a ...any) errcode.Errors {
+		return errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf(format, a...)})
+	}
+
+	switch {
+	case t == durationType || t == typesDuration:
+		d, ok := toDuration(value)
+		if !ok {
+			return appendErr("expected duration, got %v", value)
+		}
+		if rules.min != "" {
+			if min, ok := toDuration(rules.min); ok && d < min {
+				errs = appendErr("must be at least %s", rules.min)
+			}
+		}
+		if rules.max != "" {
+			if max, ok := toDuration(rules.max); ok && d > max {
+				errs = appendErr("must be at most %s", rules.max)
+			}
+		}
+		return p.validateText(key, fmt.Sprint(value), rules, errs)
+	case t == byteSizeType:
+		size, err := types.ParseByteSize(fmt.Sprint(value))
+		if err != nil {
+			return appendErr("expected byte size, got %v", value)
+		}
+		if rules.min != "" {
+			if min, err := types.ParseByteSize(rules.min); err == nil && size < min {
+				errs = appendErr("must be at least %s", rules.min)
+			}
+		}
+		if rules.max != "" {
+			if max, err := types.ParseByteSize(rules.max); err == nil && size > max {
+				errs = appendErr("must be at most %s", rules.max)
+			}
+		}
+		return p.validateText(key, fmt.Sprint(value), rules, errs)
+	case t.Kind() == reflect.Struct && isUnmarshaler(t):
+		// custom types unmarshaled by themselves, exp: types.HostPort
+		if !isScalar(value) {
+			return errs
+		}
+		return p.validateText(key, fmt.Sprint(value), rules, errs)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return appendErr("expected bool, got %v", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		n, ok := toNumber(value)
+		if !ok {
+			return appendErr("expected number, got %v", value)
+		}
+		if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 && n != math.Trunc(n) {
+			return appendErr("expected integer, got %v", value)
+		}
+		errs = p.validateRange(key, n, rules, "", errs)
+	case reflect.String:
+		if !isScalar(value) {
+			return appendErr("expected string, got %T", value)
+		}
+		errs = p.validateRange(key, float64(len([]rune(fmt.Sprint(value)))), rules, "length ", errs)
+	case reflect.Slice, reflect.Array:
+		list, ok := toList(value)
+		if !ok {
+			return appendErr("expected list, got %T", value)
+		}
+		errs = p.validateRange(key, float64(len(list)), rules, "length ", errs)
+		for i, item := range list {
+			if item == nil {
+				continue
+			}
+			errs = p.validateValue(fmt.Sprintf("%s[%d]", key, i), t.Elem(), item, &validateRules{}, errs)
+		}
+		return errs
+	case reflect.Map:
+		m, ok := toStringMap(value)
+		if !ok {
+			return appendErr("expected map, got %T", value)
+		}
+		errs = p.validateRange(key, float64(len(m)), rules, "length ", errs)
+		for _, k := range sortedKeys(m) {
+			if m[k] == nil {
+				continue
+			}
+			errs = p.validateValue(joinKey(key, k), t.Elem(), m[k], &validateRules{}, errs)
+		}
+		return errs
+	case reflect.Struct:
+		return p.validateStruct(key, t, value, errs)
+	}
+
+	return p.validateText(key, fmt.Sprint(value), rules, errs)
+}
+
+func (p *AdapterConfig) validateRange(key string, n float64, rules *validateRules, what string, errs errcode.Errors) errcode.Errors {
+	if rules.min != "" {
+		if min, err := strconv.ParseFloat(rules.min, 64); err == nil && n < min {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("%smust be at least %s", what, rules.min)})
+		}
+	}
+	if rules.max != "" {
+		if max, err := strconv.ParseFloat(rules.max, 64); err == nil && n > max {
+			errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("%smust be at most %s", what, rules.max)})
+		}
+	}
+	return errs
+}
+
+func (p *AdapterConfig) validateText(key, s string, rules *validateRules, errs errcode.Errors) errcode.Errors {
+	if len(rules.oneOf) > 0 && !types.StringInSlice(s, rules.oneOf) {
+		errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("must be one of [%s], got %q", strings.Join(rules.oneOf, " "), s)})
+	}
+	if rules.pattern != nil && !rules.pattern.MatchString(s) {
+		errs = errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf("must match pattern %q, got %q", rules.pattern, s)})
+	}
+	return errs
+}
+
+// fieldKey returns the config key of field by the yaml or json tag.
+func (p *AdapterConfig) fieldKey(field reflect.StructField) (string, bool) {
+	tagName := "yaml"
+	if p.readerType == ReaderTypeJSON {
+		tagName = "json"
+	}
+	tag, ok := field.Tag.Lookup(tagName)
+	if !ok {
+		return strings.ToLower(field.Name), false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	inline := strings.Contains(","+opts+",", ",inline,") || (tagName == "json" && name == "" && field.Anonymous)
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline
+}
+
+// JSONSchema is a JSON Schema document, supported keywords:
+// type, properties, required, additionalProperties, items, enum,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, minItems, maxItems, format (duration).
+type JSONSchema struct {
+	Type                 any                    `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+
+	pattern    *regexp.Regexp
+	additional *JSONSchema
+}
+
+// NewJSONSchema parses a JSON Schema document.
+func NewJSONSchema(data []byte) (*JSONSchema, error) {
+	s := &JSONSchema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *JSONSchema) compile() error {
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return err
+		}
+		p.pattern = re
+	}
+
+	if m, ok := p.AdditionalProperties.(map[string]any); ok {
+		bs, _ := json.Marshal(m)
+		p.additional = &JSONSchema{}
+		if err := json.Unmarshal(bs, p.additional); err != nil {
+			return err
+		}
+		if err := p.additional.compile(); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range p.Properties {
+		if err := s.compile(); err != nil {
+			return err
+		}
+	}
+	if p.Items != nil {
+		return p.Items.compile()
+	}
+	return nil
+}
+
+func (p *JSONSchema) types() []string {
+	switch t := p.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		ts := make([]string, 0, len(t))
+		for _, v := range t {
+			ts = append(ts, fmt.Sprint(v))
+		}
+		return ts
+	}
+	return nil
+}
+
+func (p *JSONSchema) validate(key string, value any, errs errcode.Errors) errcode.Errors {
+	appendErr := func(format string, a ...any) errcode.Errors {
+		return errs.Append(&ValidationError{Key: key, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if ts := p.types(); len(ts) > 0 {
+		matched := false
+		for _, t := range ts {
+			if jsonSchemaType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return appendErr("expected %s, got %T", strings.Join(ts, " or "), value)
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		found := false
+		for _, e := range p.Enum {
+			if valueEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = appendErr("must be one of %v, got %v", p.Enum, value)
+		}
+	}
+
+	if n, ok := toNumber(value); ok {
+		if p.Minimum != nil && n < *p.Minimum {
+			errs = appendErr("must be at least %v", *p.Minimum)
+		}
+		if p.Maximum != nil && n > *p.Maximum {
+			errs = appendErr("must be at most %v", *p.Maximum)
+		}
+		if p.ExclusiveMinimum != nil && n <= *p.ExclusiveMinimum {
+			errs = appendErr("must be greater than %v", *p.ExclusiveMinimum)
+		}
+		if p.ExclusiveMaximum != nil && n >= *p.ExclusiveMaximum {
+			errs = appendErr("must be less than %v", *p.ExclusiveMaximum)
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		length := len([]rune(s))
+		if p.MinLength != nil && length < *p.MinLength {
+			errs = appendErr("length must be at least %d", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			errs = appendErr("length must be at most %d", *p.MaxLength)
+		}
+		if p.pattern != nil && !p.pattern.MatchString(s) {
+			errs = appendErr("must match pattern %q, got %q", p.Pattern, s)
+		}
+		if p.Format == "duration" {
+			if _, ok := toDuration(s); !ok {
+				errs = appendErr("expected duration, got %q", s)
+			}
+		}
+	}
+
+	if list, ok := toList(value); ok {
+		if p.MinItems != nil && len(list) < *p.MinItems {
+			errs = appendErr("must have at least %d items", *p.MinItems)
+		}
+		if p.MaxItems != nil && len(list) > *p.MaxItems {
+			errs = appendErr("must have at most %d items", *p.MaxItems)
+		}
+		if p.Items != nil {
+			for i, item := range list {
+				errs = p.Items.validate(fmt.Sprintf("%s[%d]", key, i), item, errs)
+			}
+		}
+	}
+
+	if m, ok := toStringMap(value); ok {
+		for _, name := range p.Required {
+			if _, exists := m[name]; !exists {
+				errs = errs.Append(&ValidationError{Key: joinKey(key, name), Message: "is required"})
+			}
+		}
+		for _, k := range sortedKeys(m) {
+			v := m[k]
+			if s, ok := p.Properties[k]; ok {
+				errs = s.validate(joinKey(key, k), v, errs)
+				continue
+			}
+			switch {
+			case p.additional != nil:
+				errs = p.additional.validate(joinKey(key, k), v, errs)
+			case p.AdditionalProperties == false:
+				errs = errs.Append(&ValidationError{Key: joinKey(key, k), Message: "is not allowed"})
+			}
+		}
+	}
+
+	return errs
+}
+
+func jsonSchemaType(t string, value any) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toNumber(value)
+		return ok
+	case "integer":
+		n, ok := toNumber(value)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := toList(value)
+		return ok
+	case "object":
+		_, ok := toStringMap(value)
+		return ok
+	}
+	return false
+}
+
+func valueEqual(a, b any) bool {
+	an, aok := toNumber(a)
+	bn, bok := toNumber(b)
+	if aok && bok {
+		return an == bn
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toNumber(value any) (float64, bool) {
+	if n, ok := value.(interface{ Float64() (float64, error) }); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func toDuration(value any) (time.Duration, bool) {
+	if n, ok := toNumber(value); ok {
+		return time.Duration(n), true
+	}
+	s, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	d := types.ParseStringTime(strings.ToLower(s), -1)
+	return d, d >= 0
+}
+
+func isUnmarshaler(t reflect.Type) bool {
+	for _, u := range unmarshalers {
+		if reflect.PointerTo(t).Implements(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func isScalar(value any) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return false
+	}
+	return true
+}
+
+func toStringMap(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case Options:
+		return v, true
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = item
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+func toList(value any) ([]any, bool) {
+	if list, ok := value.([]any); ok {
+		return list, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]any, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lookupKey(values map[string]any, name string) (any, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	for k, v := range values {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/errors/errcode"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/go-trellis/common/utils/types"
+)
+
+type validateDatabase struct {
+	Host    string         `yaml:"host" validate:"required"`
+	Port    int            `yaml:"port" validate:"min=1,max=65535"`
+	Driver  string         `yaml:"driver" validate:"oneof=mysql postgres"`
+	Timeout types.Duration `yaml:"timeout" validate:"min=1s,max=1m"`
+}
+
+type validateUser struct {
+	Name string `yaml:"name" validate:"required,pattern=^[a-z]+$"`
+}
+
+type validateSchema struct {
+	Database validateDatabase `yaml:"database" validate:"required"`
+	Users    []validateUser   `yaml:"users" validate:"min=1"`
+	Cache    struct {
+		TTL time.Duration `yaml:"ttl" validate:"required"`
+	} `yaml:"cache"`
+	Address types.HostPort `yaml:"address"`
+}
+
+const validateYAML = `
+database:
+  port: 70000
+  driver: oracle
+  timeout: 5m
+users:
+  - name: admin
+  - name: Bad-Name
+cache:
+  ttl: 10s
+address: "127.0.0.1:8080"
+`
+
+func TestValidate_Struct(t *testing.T) {
+	cfg, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, validateYAML))
+	testutils.Ok(t, err)
+
+	err = cfg.Validate(&validateSchema{})
+	testutils.NotOk(t, err)
+
+	errs, ok := err.(errcode.Errors)
+	testutils.Assert(t, ok, "error should be errcode.Errors, got %T", err)
+
+	var keys []string
+	for _, e := range errs {
+		ve, ok := e.(*config.ValidationError)
+		testutils.Assert(t, ok, "error should be *config.ValidationError, got %T", e)
+		keys = append(keys, ve.Key)
+	}
+	testutils.Equals(t, []string{
+		"database.host",
+		"database.port",
+		"database.driver",
+		"database.timeout",
+		"users[1].name",
+	}, keys)
+	testutils.Equals(t, "database.host: is required", errs[0].Error())
+
+	err = cfg.SetKeyValue("database", map[string]any{"host": "localhost", "port": 3306, "timeout": "30s"})
+	testutils.Ok(t, err)
+	err = cfg.SetKeyValue("users", []any{map[string]any{"name": "admin"}})
+	testutils.Ok(t, err)
+	testutils.Ok(t, cfg.Validate(validateSchema{}))
+
+	testutils.ErrorEqual(t, config.ErrInvalidSchema, cfg.Validate("schema"))
+}
+
+const validateJSONSchema = `{
+  "type": "object",
+  "required": ["database", "log"],
+  "properties": {
+    "database": {
+      "type": "object",
+      "required": ["host"],
+      "additionalProperties": false,
+      "properties": {
+        "host": {"type": "string", "minLength": 1},
+        "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+      }
+    },
+    "users": {
+      "type": "array",
+      "minItems": 1,
+      "items": {"type": "object", "properties": {"name": {"type": "string", "pattern": "^[a-z]+$"}}}
+    },
+    "timeout": {"type": "string", "format": "duration"},
+    "level": {"enum": ["debug", "info"]}
+  }
+}`
+
+func TestValidate_JSONSchema(t *testing.T) {
+	schema, err := config.NewJSONSchema([]byte(validateJSONSchema))
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeJSON, `{
+  "database": {"port": 0, "user": "root"},
+  "users": [{"name": "admin"}, {"name": "Bad"}],
+  "timeout": "soon",
+  "level": "trace"
+}`))
+	testutils.Ok(t, err)
+
+	err = cfg.Validate(schema)
+	testutils.NotOk(t, err)
+
+	var messages []string
+	for _, e := range err.(errcode.Errors) {
+		messages = append(messages, e.Error())
+	}
+	testutils.Equals(t, []string{
+		"log: is required",
+		"database.host: is required",
+		"database.port: must be at least 1",
+		"database.user: is not allowed",
+		`level: must be one of [debug info], got trace`,
+		`timeout: expected duration, got "soon"`,
+		`users[1].name: must match pattern "^[a-z]+$", got "Bad"`,
+	}, messages)
+
+	_, err = config.NewJSONSchema([]byte(`{"pattern": "[a-"}`))
+	testutils.NotOk(t, err)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval for checking config files.
+const DefaultWatchInterval = 2 * time.Second
+
+// WatchFunc is called with snapshots of the config before and after a reload.
+type WatchFunc func(old, new Config)
+
+// Watcher reloads a config when its file or any of its included files changes.
+type Watcher interface {
+	// Watch registers fn to be called when the value of key or any of its children changes,
+	// an empty key watches the whole config
+	Watch(key string, fn WatchFunc)
+	// Subscribe registers fn to be called with all changed keys after each reload
+	Subscribe(fn func(keys []string))
+	// Reload reads the config files immediately and notifies the changes
+	Reload() error
+	// Close stops watching the files
+	Close() error
+}
+
+// WatchOption watcher option function type.
+type WatchOption func(*WatchOptions)
+
+// WatchOptions watcher options
+type WatchOptions struct {
+	Interval time.Duration
+	OnError  func(error)
+}
+
+// WatchInterval sets the interval for checking the config files.
+func WatchInterval(interval time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		o.Interval = interval
+	}
+}
+
+// WatchOnError sets the handler of reload errors, the last good config is kept on error.
+func WatchOnError(fn func(error)) WatchOption {
+	return func(o *WatchOptions) {
+		o.OnError = fn
+	}
+}
+
+type keyWatcher struct {
+	key string
+	fn  WatchFunc
+}
+
+// notifier dispatches config changes to watchers and subscribers.
+type notifier struct {
+	mu          sync.RWMutex
+	watchers    []keyWatcher
+	subscribers []func(keys []string)
+}
+
+func (p *notifier) Watch(key string, fn WatchFunc) {
+	if fn == nil {
+		return
+	}
+	p.mu.Lock()
+	p.watchers = append(p.watchers, keyWatcher{key: key, fn: fn})
+	p.mu.Unlock()
+}
+
+func (p *notifier) Subscribe(fn func(keys []string)) {
+	if fn == nil {
+		return
+	}
+	p.mu.Lock()
+	p.subscribers = append(p.subscribers, fn)
+	p.mu.Unlock()
+}
+
+func (p *notifier) notify(keys []string, old, new Config) {
+	if len(keys) == 0 {
+		return
+	}
+
+	p.mu.RLock()
+	watchers := append([]keyWatcher(nil), p.watchers...)
+	subscribers := make([]func(keys []string), len(p.subscribers))
+	copy(subscribers, p.subscribers)
+	p.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		sub(keys)
+	}
+
+	for _, w := range watchers {
+		if keyChanged(w.key, keys) {
+			w.fn(old, new)
+		}
+	}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type fileWatcher struct {
+	notifier
+
+	config  *AdapterConfig
+	options WatchOptions
+
+	mu     sync.Mutex
+	stamps map[string]fileStamp
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWatcher watches the files of c, which must be created from a config file,
+// and swaps the values of c when the files change.
+func NewWatcher(c Config, opts ...WatchOption) (Watcher, error) {
+	ac, ok := c.(*AdapterConfig)
+	if !ok || len(ac.ConfigFile) == 0 {
+		return nil, ErrNotWatchable
+	}
+
+	w := &fileWatcher{
+		config: ac,
+		stop:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&w.options)
+	}
+	if w.options.Interval <= 0 {
+		w.options.Interval = DefaultWatchInterval
+	}
+
+	ac.locker.RLock()
+	w.stamps = statFiles(ac.files)
+	ac.locker.RUnlock()
+
+	go w.run()
+
+	return w, nil
+}
+
+func (p *fileWatcher) run() {
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			changed := filesChanged(p.stamps)
+			p.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if err := p.Reload(); err != nil && p.options.OnError != nil {
+				p.options.OnError(err)
+			}
+		}
+	}
+}
+
+// Reload reloads the files, the watchers are called out of the lock, so they can call Reload too.
+func (p *fileWatcher) Reload() error {
+	p.mu.Lock()
+	fresh := &AdapterConfig{
+		ConfigFile:       p.config.ConfigFile,
+		EnvPrefix:        p.config.EnvPrefix,
+		EnvAllowed:       p.config.EnvAllowed,
+		strictReferences: p.config.strictReferences,
+		secretKey:        p.config.secretKey,
+		secretKeyFile:    p.config.secretKeyFile,
+		secretKeyEnv:     p.config.secretKeyEnv,
+	}
+	err := fresh.init()
+	if err != nil {
+		// the files are broken, do not reload them again until they change
+		p.stamps = statFiles(append(p.config.filesSnapshot(), fresh.files...))
+		p.mu.Unlock()
+		return err
+	}
+	p.stamps = statFiles(fresh.files)
+
+	old := p.config.swap(fresh.configs, fresh.files, fresh.secrets)
+	p.mu.Unlock()
+
+	keys := changedKeys(old.configs, fresh.configs)
+	p.notify(keys, old, fresh.copy())
+	return nil
+}
+
+func (p *fileWatcher) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+	return nil
+}
+
+// swap replaces the values of the config, and returns a config with the old values.
+func (p *AdapterConfig) swap(configs map[string]any, files []string, secrets map[string]string) *AdapterConfig {
+	p.locker.Lock()
+	old := &AdapterConfig{
+		ConfigFile: p.ConfigFile,
+		readerType: p.readerType,
+		reader:     p.reader,
+		configs:    p.configs,
+		files:      p.files,
+		secrets:    p.secrets,
+	}
+	p.configs = configs
+	p.secrets = secrets
+	if files != nil {
+		p.files = files
+	}
+	p.locker.Unlock()
+	return old
+}
+
+func (p *AdapterConfig) filesSnapshot() []string {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	return append([]string(nil), p.files...)
+}
+
+func statFiles(names []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(names))
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			stamps[name] = fileStamp{}
+			continue
+		}
+		stamps[name] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return stamps
+}
+
+func filesChanged(stamps map[string]fileStamp) bool {
+	for name, stamp := range stamps {
+		fi, err := os.Stat(name)
+		if err != nil {
+			if !stamp.modTime.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !fi.ModTime().Equal(stamp.modTime) || fi.Size() != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// keyChanged reports whether key, its parents or its children are in keys.
+func keyChanged(key string, keys []string) bool {
+	if key == "" {
+		return true
+	}
+	for _, k := range keys {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// changedKeys returns the sorted leaf key paths whose values differ between old and new.
+func changedKeys(old, new map[string]any) []string {
+	oldValues, newValues := map[string]any{}, map[string]any{}
+	flattenValues("", old, oldValues)
+	flattenValues("", new, newValues)
+
+	var keys []string
+	for k, ov := range oldValues {
+		nv, ok := newValues[k]
+		if !ok || !reflect.DeepEqual(ov, nv) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flattenValues sets the leaf values of maps into values with dot separated keys.
+func flattenValues(prefix string, value any, values map[string]any) {
+	var m map[string]any
+	switch v := value.(type) {
+	case map[string]any:
+		m = v
+	case Options:
+		m = v
+	case map[any]any:
+		m = make(map[string]any, len(v))
+		for k, item := range v {
+			sk, ok := k.(string)
+			if !ok {
+				continue
+			}
+			m[sk] = item
+		}
+	default:
+		values[prefix] = value
+		return
+	}
+
+	if len(m) == 0 && prefix != "" {
+		values[prefix] = value
+		return
+	}
+
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		flattenValues(key, v, values)
+	}
+}
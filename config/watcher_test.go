@@ -0,0 +1,158 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	includedFile := filepath.Join(tmpDir, "included.yml")
+	err := os.WriteFile(includedFile, []byte("log:\n  level: info\n"), 0644)
+	testutils.Ok(t, err)
+
+	mainFile := filepath.Join(tmpDir, "main.yml")
+	err = os.WriteFile(mainFile, []byte(`"#include": "included.yml"
+ratelimit:
+  qps: 100
+`), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfig(mainFile)
+	testutils.Ok(t, err)
+
+	w, err := config.NewWatcher(cfg, config.WatchInterval(time.Hour))
+	testutils.Ok(t, err)
+	defer w.Close()
+
+	var changed []string
+	w.Subscribe(func(keys []string) { changed = keys })
+
+	var oldQPS, newQPS int
+	w.Watch("ratelimit", func(old, new config.Config) {
+		oldQPS, newQPS = old.GetInt("ratelimit.qps"), new.GetInt("ratelimit.qps")
+	})
+	logCalled := false
+	w.Watch("log", func(old, new config.Config) { logCalled = true })
+
+	err = os.WriteFile(mainFile, []byte(`"#include": "included.yml"
+ratelimit:
+  qps: 200
+`), 0644)
+	testutils.Ok(t, err)
+	testutils.Ok(t, w.Reload())
+
+	testutils.Equals(t, []string{"ratelimit.qps"}, changed)
+	testutils.Equals(t, 100, oldQPS)
+	testutils.Equals(t, 200, newQPS)
+	testutils.Assert(t, !logCalled, "log watcher should not be called")
+	testutils.Equals(t, 200, cfg.GetInt("ratelimit.qps"))
+
+	// changes of included files are reloaded too
+	err = os.WriteFile(includedFile, []byte("log:\n  level: debug\n"), 0644)
+	testutils.Ok(t, err)
+	testutils.Ok(t, w.Reload())
+	testutils.Assert(t, logCalled, "log watcher should be called")
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+
+	// broken files keep the last good config
+	err = os.WriteFile(mainFile, []byte("ratelimit: [qps: 300\n"), 0644)
+	testutils.Ok(t, err)
+	testutils.NotOk(t, w.Reload())
+	testutils.Equals(t, 200, cfg.GetInt("ratelimit.qps"))
+	testutils.Equals(t, "debug", cfg.GetString("log.level"))
+}
+
+func TestWatcher_Polling(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mainFile := filepath.Join(tmpDir, "main.json")
+	err := os.WriteFile(mainFile, []byte(`{"feature": {"enabled": false}}`), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewConfig(mainFile)
+	testutils.Ok(t, err)
+
+	w, err := config.NewWatcher(cfg, config.WatchInterval(10*time.Millisecond))
+	testutils.Ok(t, err)
+	defer w.Close()
+
+	reloaded := make(chan bool, 1)
+	w.Watch("feature.enabled", func(_, new config.Config) {
+		reloaded <- new.GetBoolean("feature.enabled")
+	})
+
+	err = os.WriteFile(mainFile, []byte(`{"feature": {"enabled": true, "name": "x"}}`), 0644)
+	testutils.Ok(t, err)
+
+	select {
+	case enabled := <-reloaded:
+		testutils.Assert(t, enabled, "feature should be enabled")
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	testutils.Assert(t, cfg.GetBoolean("feature.enabled"), "feature should be enabled")
+}
+
+func TestWatcher_AdapterConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mainFile := filepath.Join(tmpDir, "main.yml")
+	err := os.WriteFile(mainFile, []byte("ratelimit:\n  qps: 100\n"), 0644)
+	testutils.Ok(t, err)
+
+	cfg, err := config.NewAdapterConfig(mainFile)
+	testutils.Ok(t, err)
+
+	w, err := config.NewWatcher(cfg, config.WatchInterval(10*time.Millisecond))
+	testutils.Ok(t, err)
+	defer w.Close()
+
+	reloaded := make(chan error, 1)
+	w.Watch("ratelimit", func(_, _ config.Config) {
+		// watchers can reload the config without deadlock
+		reloaded <- w.Reload()
+	})
+
+	err = os.WriteFile(mainFile, []byte("ratelimit:\n  qps: 200\n"), 0644)
+	testutils.Ok(t, err)
+
+	select {
+	case err = <-reloaded:
+		testutils.Ok(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	testutils.Equals(t, 200, cfg.GetInt("ratelimit.qps"))
+}
+
+func TestWatcher_NotWatchable(t *testing.T) {
+	cfg, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, "a: b"))
+	testutils.Ok(t, err)
+
+	_, err = config.NewWatcher(cfg)
+	testutils.ErrorEqual(t, config.ErrNotWatchable, err)
+}
@@ -196,7 +196,7 @@ func (p *Plugins) runPlugin(plugin *Plugin) func() {
 		evalTotalCounter.WithLabelValues(plugin.config.Name).Add(1)
 
 		if err := plugin.config.FN(); err != nil {
-			p.logger.Error("msg", "eval_function_failed", "error", err)
+			p.logger.With("plugin", plugin.config.Name, "error", err).Error("eval function failed")
 			evalFailureTotalCounter.WithLabelValues(plugin.config.Name).Add(1)
 		} else {
 			evalSuccessTotalCounter.WithLabelValues(plugin.config.Name).Add(1)
@@ -0,0 +1,48 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-trellis/common/logger"
+	"github.com/go-trellis/common/utils/testutils"
+	"xorm.io/xorm/log"
+)
+
+func TestPlugins_RunPluginFailure(t *testing.T) {
+	rec := logger.NewRecorder()
+	plugins, err := NewPlugins(Logger(rec))
+	testutils.Ok(t, err)
+
+	_, err = plugins.RegisterPlugin(&Config{
+		Name:       "failing",
+		CronConfig: "@every 1h",
+		FN:         func() error { return errors.New("boom") },
+	})
+	testutils.Ok(t, err)
+
+	plugins.runPlugin(plugins.plugins["failing"])()
+
+	errs := rec.FilterLevel(log.LOG_ERR)
+	testutils.Equals(t, 1, len(errs), "entries: %s", rec)
+	testutils.Equals(t, "eval function failed", errs[0].Message)
+	testutils.ContainsField(t, rec, "plugin", "failing")
+	testutils.Equals(t, "boom", errs[0].Fields["error"].(error).Error())
+}
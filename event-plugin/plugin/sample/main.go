@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -102,6 +103,11 @@ func (l *Logger) With(kvs ...any) logger.Logger {
 	return l
 }
 
+func (l *Logger) WithContext(ctx context.Context) logger.Logger {
+	// the sample logger prints no trace_id and request_id, so ctx is ignored
+	return l
+}
+
 func (l *Logger) Writer() io.Writer {
 	return os.Stdout
 }
@@ -0,0 +1,323 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OverflowPolicy defines what AsyncWriter does when its buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the writes until the buffer has room
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest drops the incoming writes
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest drops the oldest buffered writes to make room
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+)
+
+const (
+	// DefaultAsyncBufferSize is the default number of buffered writes
+	DefaultAsyncBufferSize = 4096
+	// DefaultAsyncFlushInterval is the default interval of background flushing
+	DefaultAsyncFlushInterval = 100 * time.Millisecond
+)
+
+// ErrAsyncWriterClosed is returned by writing into a closed AsyncWriter
+var ErrAsyncWriterClosed = errors.New("async writer is closed")
+
+var (
+	asyncQueuedBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "trellis",
+			Name:      "logger_async_queued_bytes",
+			Help:      "The bytes buffered in the async log writer.",
+		},
+		[]string{"writer"},
+	)
+	asyncDroppedBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "trellis",
+			Name:      "logger_async_dropped_bytes_total",
+			Help:      "The total bytes dropped by the async log writer when its buffer is full.",
+		},
+		[]string{"writer"},
+	)
+	asyncDroppedWritesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "trellis",
+			Name:      "logger_async_dropped_writes_total",
+			Help:      "The total writes dropped by the async log writer when its buffer is full.",
+		},
+		[]string{"writer"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(asyncQueuedBytesGauge, asyncDroppedBytesCounter, asyncDroppedWritesCounter)
+}
+
+// AsyncOption sets the options of AsyncWriter
+type AsyncOption func(*AsyncWriter)
+
+// AsyncBufferSize sets the number of buffered writes
+func AsyncBufferSize(size int) AsyncOption {
+	return func(p *AsyncWriter) {
+		p.bufferSize = size
+	}
+}
+
+// AsyncFlushInterval sets the interval of background flushing,
+// the buffer is also flushed when it is half full
+func AsyncFlushInterval(interval time.Duration) AsyncOption {
+	return func(p *AsyncWriter) {
+		p.flushInterval = interval
+	}
+}
+
+// AsyncOverflowPolicy sets the policy when the buffer is full, default: OverflowBlock
+func AsyncOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return func(p *AsyncWriter) {
+		p.policy = policy
+	}
+}
+
+// AsyncName sets the "writer" label of metrics, default: "default"
+func AsyncName(name string) AsyncOption {
+	return func(p *AsyncWriter) {
+		p.name = name
+	}
+}
+
+// AsyncWriter buffers the writes in a bounded ring buffer and writes them into the underlying writer in the background
+type AsyncWriter struct {
+	writer        io.Writer
+	name          string
+	policy        OverflowPolicy
+	bufferSize    int
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    [][]byte
+	head    int
+	size    int
+	closed  bool
+	err     error
+
+	// writeMu keeps the order of batches written by the flusher and Flush
+	writeMu sync.Mutex
+
+	wake      chan struct{}
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	dropped   atomic.Uint64
+
+	queuedBytes   prometheus.Gauge
+	droppedBytes  prometheus.Counter
+	droppedWrites prometheus.Counter
+}
+
+// NewAsyncWriter wraps w with a background flusher, call Close to flush the buffered writes and close w
+func NewAsyncWriter(w io.Writer, opts ...AsyncOption) (*AsyncWriter, error) {
+	if w == nil {
+		return nil, fmt.Errorf("writer is required")
+	}
+
+	p := &AsyncWriter{
+		writer:        w,
+		name:          "default",
+		policy:        OverflowBlock,
+		flushInterval: DefaultAsyncFlushInterval,
+		bufferSize:    DefaultAsyncBufferSize,
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.bufferSize <= 0 {
+		return nil, fmt.Errorf("buffer size should be positive")
+	}
+	if p.flushInterval <= 0 {
+		return nil, fmt.Errorf("flush interval should be positive")
+	}
+	switch p.policy {
+	case OverflowBlock, OverflowDropNewest, OverflowDropOldest:
+	default:
+		return nil, fmt.Errorf("unknown overflow policy: %s", p.policy)
+	}
+
+	p.ring = make([][]byte, p.bufferSize)
+	p.notFull = sync.NewCond(&p.mu)
+	p.queuedBytes = asyncQueuedBytesGauge.WithLabelValues(p.name)
+	p.droppedBytes = asyncDroppedBytesCounter.WithLabelValues(p.name)
+	p.droppedWrites = asyncDroppedWritesCounter.WithLabelValues(p.name)
+
+	go p.run()
+	return p, nil
+}
+
+// Write copies bs into the buffer, it blocks or drops writes by the overflow policy if the buffer is full
+func (p *AsyncWriter) Write(bs []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for !p.closed && p.size == len(p.ring) {
+		switch p.policy {
+		case OverflowDropNewest:
+			p.drop(len(bs))
+			return len(bs), nil
+		case OverflowDropOldest:
+			p.drop(len(p.pop()))
+		default:
+			p.notify()
+			p.notFull.Wait()
+		}
+	}
+	if p.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+
+	p.push(append([]byte(nil), bs...))
+	if p.size >= (len(p.ring)+1)/2 {
+		p.notify()
+	}
+	return len(bs), nil
+}
+
+// Flush writes the buffered writes into the underlying writer, and returns the last error of writing
+func (p *AsyncWriter) Flush() error {
+	p.flush()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.err
+	p.err = nil
+	return err
+}
+
+// Close stops the flusher, flushes the buffered writes and closes the underlying writer if it is an io.Closer
+func (p *AsyncWriter) Close() error {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		p.notFull.Broadcast()
+		p.mu.Unlock()
+
+		close(p.stop)
+		<-p.stopped
+
+		err := p.Flush()
+		if closer, ok := p.writer.(io.Closer); ok {
+			err = errors.Join(err, closer.Close())
+		}
+		p.closeErr = err
+	})
+	return p.closeErr
+}
+
+// Dropped returns the number of writes dropped by the overflow policy
+func (p *AsyncWriter) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+func (p *AsyncWriter) run() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+		p.flush()
+	}
+}
+
+func (p *AsyncWriter) flush() {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	p.mu.Lock()
+	batch := make([][]byte, 0, p.size)
+	for p.size > 0 {
+		batch = append(batch, p.pop())
+	}
+	p.notFull.Broadcast()
+	p.mu.Unlock()
+
+	var err error
+	for _, bs := range batch {
+		if _, e := p.writer.Write(bs); e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+	}
+}
+
+func (p *AsyncWriter) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *AsyncWriter) push(bs []byte) {
+	p.ring[(p.head+p.size)%len(p.ring)] = bs
+	p.size++
+	p.queuedBytes.Add(float64(len(bs)))
+}
+
+func (p *AsyncWriter) pop() []byte {
+	bs := p.ring[p.head]
+	p.ring[p.head] = nil
+	p.head = (p.head + 1) % len(p.ring)
+	p.size--
+	p.queuedBytes.Sub(float64(len(bs)))
+	return bs
+}
+
+func (p *AsyncWriter) drop(n int) {
+	p.dropped.Add(1)
+	p.droppedWrites.Inc()
+	p.droppedBytes.Add(float64(n))
+}
+
+var _ io.WriteCloser = (*AsyncWriter)(nil)
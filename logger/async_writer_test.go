@@ -0,0 +1,193 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gateWriter blocks the writes until the gate is opened
+type gateWriter struct {
+	gate   chan struct{}
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{})}
+}
+
+func (p *gateWriter) Write(bs []byte) (int, error) {
+	<-p.gate
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.Write(bs)
+}
+
+func (p *gateWriter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *gateWriter) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := newGateWriter()
+	close(w.gate)
+
+	aw, err := NewAsyncWriter(w, AsyncName("test"), AsyncFlushInterval(time.Hour))
+	testutils.Ok(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = fmt.Fprintf(aw, "line %d\n", i)
+		testutils.Ok(t, err)
+	}
+	testutils.Equals(t, float64(len("line 0\n")*3), testutil.ToFloat64(asyncQueuedBytesGauge.WithLabelValues("test")))
+
+	testutils.Ok(t, aw.Flush())
+	testutils.Equals(t, "line 0\nline 1\nline 2\n", w.String())
+	testutils.Equals(t, float64(0), testutil.ToFloat64(asyncQueuedBytesGauge.WithLabelValues("test")))
+
+	_, err = aw.Write([]byte("last\n"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, aw.Close())
+	testutils.Ok(t, aw.Close())
+	testutils.Equals(t, "line 0\nline 1\nline 2\nlast\n", w.String())
+	testutils.Assert(t, w.closed, "underlying writer should be closed")
+
+	_, err = aw.Write([]byte("closed\n"))
+	testutils.Equals(t, ErrAsyncWriterClosed, err)
+
+	_, err = NewAsyncWriter(w, AsyncOverflowPolicy("unknown"))
+	testutils.NotOk(t, err)
+	_, err = NewAsyncWriter(w, AsyncBufferSize(0))
+	testutils.NotOk(t, err)
+}
+
+func TestAsyncWriter_Overflow(t *testing.T) {
+	for _, c := range []struct {
+		policy   OverflowPolicy
+		expected string
+	}{
+		{policy: OverflowDropNewest, expected: "0\n1\n"},
+		{policy: OverflowDropOldest, expected: "3\n4\n"},
+	} {
+		t.Run(string(c.policy), func(t *testing.T) {
+			name := "overflow_" + string(c.policy)
+			droppedWrites := testutil.ToFloat64(asyncDroppedWritesCounter.WithLabelValues(name))
+			droppedBytes := testutil.ToFloat64(asyncDroppedBytesCounter.WithLabelValues(name))
+			w := newGateWriter()
+			close(w.gate)
+
+			aw, err := NewAsyncWriter(w, AsyncName(name), AsyncBufferSize(2),
+				AsyncFlushInterval(time.Hour), AsyncOverflowPolicy(c.policy))
+			testutils.Ok(t, err)
+
+			// the flusher is woken up when the buffer is half full, so it is held by the lock of writing
+			aw.writeMu.Lock()
+			for i := 0; i < 5; i++ {
+				_, err = fmt.Fprintf(aw, "%d\n", i)
+				testutils.Ok(t, err)
+			}
+			aw.writeMu.Unlock()
+
+			testutils.Ok(t, aw.Close())
+			testutils.Equals(t, c.expected, w.String())
+			testutils.Equals(t, uint64(3), aw.Dropped())
+			testutils.Equals(t, droppedWrites+3, testutil.ToFloat64(asyncDroppedWritesCounter.WithLabelValues(name)))
+			testutils.Equals(t, droppedBytes+6, testutil.ToFloat64(asyncDroppedBytesCounter.WithLabelValues(name)))
+		})
+	}
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	w := newGateWriter()
+	aw, err := NewAsyncWriter(w, AsyncBufferSize(1), AsyncFlushInterval(time.Millisecond))
+	testutils.Ok(t, err)
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(aw, "%d\n", i)
+		}
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("writes should be blocked by the full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-written
+	testutils.Ok(t, aw.Close())
+	testutils.Equals(t, "0\n1\n2\n", w.String())
+	testutils.Equals(t, uint64(0), aw.Dropped())
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestAsyncWriter_Error(t *testing.T) {
+	aw, err := NewAsyncWriter(errWriter{}, AsyncFlushInterval(time.Hour))
+	testutils.Ok(t, err)
+
+	_, err = aw.Write([]byte("line\n"))
+	testutils.Ok(t, err)
+	testutils.NotOk(t, aw.Flush())
+	testutils.Ok(t, aw.Flush())
+	testutils.Ok(t, aw.Close())
+}
+
+func TestAsyncWriter_RotateLogs(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	rw, err := NewRotateLogsWriter(&RotateLogsConfig{LogPath: logPath, RotateMode: RotateModeDay})
+	testutils.Ok(t, err)
+
+	aw, err := NewAsyncWriter(rw)
+	testutils.Ok(t, err)
+
+	l, _ := newBufferLogger(t, FormatLogfmt)
+	l.logger.SetOutput(aw)
+	l.Info("async")
+	testutils.Ok(t, aw.Close())
+
+	data, err := os.ReadFile(logPath)
+	testutils.Ok(t, err)
+	testutils.Assert(t, strings.Contains(string(data), "msg=async"), "log file: %s", data)
+}
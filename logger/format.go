@@ -0,0 +1,189 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-trellis/common/utils/json"
+	"github.com/sirupsen/logrus"
+)
+
+// Format defines the output format of log entries
+type Format string
+
+const (
+	// FormatText is the logrus text output, it is the default format
+	FormatText Format = "text"
+	// FormatJSON writes an entry as a JSON object per line, structs and maps of fields are nested objects
+	FormatJSON Format = "json"
+	// FormatLogfmt writes an entry as key=value pairs per line, structs and maps of fields are JSON strings
+	FormatLogfmt Format = "logfmt"
+)
+
+// Validate checks the format is known, the empty format is FormatText
+func (f Format) Validate() error {
+	switch f {
+	case "", FormatText, FormatJSON, FormatLogfmt:
+		return nil
+	default:
+		return fmt.Errorf("unknown log format: %s", f)
+	}
+}
+
+// NewFormatter returns the logrus formatter of the format
+func NewFormatter(format Format) (logrus.Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return &logrus.TextFormatter{}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}, nil
+	case FormatLogfmt:
+		return &LogfmtFormatter{}, nil
+	default:
+		return nil, format.Validate()
+	}
+}
+
+// LogfmtFormatter formats entries as logfmt: time=... level=info msg="..." key=value
+//
+// The fields are sorted by keys after time, level and msg,
+// fields named as the builtin keys are prefixed with "fields.".
+type LogfmtFormatter struct {
+	// TimestampFormat is the layout of time, default: time.RFC3339Nano
+	TimestampFormat string
+	// DisableTimestamp omits the time key
+	DisableTimestamp bool
+}
+
+// Format implements logrus.Formatter
+func (p *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+
+	if !p.DisableTimestamp {
+		layout := p.TimestampFormat
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		appendLogfmt(b, logrus.FieldKeyTime, entry.Time.Format(layout))
+	}
+	appendLogfmt(b, logrus.FieldKeyLevel, entry.Level.String())
+	appendLogfmt(b, logrus.FieldKeyMsg, entry.Message)
+	if entry.HasCaller() {
+		appendLogfmt(b, logrus.FieldKeyFunc, entry.Caller.Function)
+		appendLogfmt(b, logrus.FieldKeyFile, fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := k
+		switch k {
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, logrus.FieldKeyFunc, logrus.FieldKeyFile:
+			key = "fields." + k
+		}
+		appendLogfmt(b, key, logfmtValue(entry.Data[k]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func appendLogfmt(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+		return
+	}
+	b.WriteString(value)
+}
+
+func logfmtValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(bs)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func needsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHook writes the entries of levels into writer by its own formatter
+type formatHook struct {
+	writer    io.Writer
+	formatter logrus.Formatter
+	levels    []logrus.Level
+}
+
+func (p *formatHook) Levels() []logrus.Level {
+	return p.levels
+}
+
+func (p *formatHook) Fire(entry *logrus.Entry) error {
+	bs, err := p.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	if w, ok := p.writer.(levelWriter); ok {
+		return w.WriteLevel(entry.Level, bs)
+	}
+	_, err = p.writer.Write(bs)
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/middleware/tracing"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newBufferLogger(t *testing.T, format Format) (*LogrusLogger, *bytes.Buffer) {
+	l, err := NewLogrusLogger(OptionFormat(format))
+	testutils.Ok(t, err)
+	buf := &bytes.Buffer{}
+	l.logger.SetOutput(buf)
+	return l, buf
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	entry := &logrus.Entry{
+		Time:    time.Date(2025, 6, 13, 14, 0, 0, 0, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "user login",
+		Data: logrus.Fields{
+			"user":  user{Name: "henry", Age: 18},
+			"path":  "/api/v1",
+			"query": "a=b",
+			"err":   errors.New("bad request"),
+			"empty": "",
+			"msg":   "clash",
+		},
+	}
+
+	bs, err := (&LogfmtFormatter{}).Format(entry)
+	testutils.Ok(t, err)
+	testutils.Equals(t, `time=2025-06-13T14:00:00Z level=info msg="user login" empty="" err="bad request" fields.msg=clash path=/api/v1 query="a=b" user="{\"name\":\"henry\",\"age\":18}"`+"\n", string(bs))
+
+	bs, err = (&LogfmtFormatter{DisableTimestamp: true}).Format(&logrus.Entry{Level: logrus.WarnLevel, Message: "ok"})
+	testutils.Ok(t, err)
+	testutils.Equals(t, "level=warning msg=ok\n", string(bs))
+}
+
+func TestLogrusLogger_FormatJSON(t *testing.T) {
+	l, buf := newBufferLogger(t, FormatJSON)
+
+	l.With("user", user{Name: "henry", Age: 18}, "id", 1).Info("user login")
+
+	var out map[string]any
+	testutils.Ok(t, json.Unmarshal(buf.Bytes(), &out))
+	testutils.Equals(t, "user login", out["msg"])
+	testutils.Equals(t, "info", out["level"])
+	testutils.Equals(t, float64(1), out["id"])
+	testutils.Equals(t, map[string]any{"name": "henry", "age": float64(18)}, out["user"])
+}
+
+func TestLogrusLogger_FormatLogfmt(t *testing.T) {
+	l, buf := newBufferLogger(t, FormatLogfmt)
+
+	l.With("id", 1).Info("user login")
+	testutils.Assert(t, strings.HasSuffix(buf.String(), ` level=info msg="user login" id=1`+"\n"), "logfmt output: %s", buf.String())
+
+	testutils.NotOk(t, l.SetFormat("xml"))
+	_, err := NewLogrusLogger(OptionFormat("xml"))
+	testutils.NotOk(t, err)
+}
+
+func TestLogrusLogger_WithContext(t *testing.T) {
+	l, buf := newBufferLogger(t, FormatLogfmt)
+
+	ctx := tracing.WithTraceID(context.Background(), "trace-1")
+	ctx = tracing.WithRequestID(ctx, "request-1")
+
+	l.WithContext(ctx).Info("handled")
+	testutils.Assert(t, strings.HasSuffix(buf.String(), " request_id=request-1 trace_id=trace-1\n"), "output: %s", buf.String())
+
+	buf.Reset()
+	l.With("id", 1).WithContext(tracing.WithTraceID(context.Background(), "trace-2")).Info("handled")
+	testutils.Assert(t, strings.HasSuffix(buf.String(), " id=1 trace_id=trace-2\n"), "output: %s", buf.String())
+
+	buf.Reset()
+	l.WithContext(context.Background()).Info("handled")
+	testutils.Assert(t, !strings.Contains(buf.String(), "_id="), "output: %s", buf.String())
+
+	buf.Reset()
+	var nilCtx context.Context
+	l.WithContext(nilCtx).Info("handled")
+	testutils.Assert(t, !strings.Contains(buf.String(), "_id="), "output: %s", buf.String())
+
+	testutils.Assert(t, Noop().WithContext(ctx) != nil, "noop WithContext should not be nil")
+}
+
+func TestRotateLogsConfig_Format(t *testing.T) {
+	var config RotateLogsConfig
+	testutils.Ok(t, yaml.Unmarshal([]byte("log_path: /tmp/test.log\nformat: json\n"), &config))
+	testutils.Equals(t, FormatJSON, config.Format)
+	testutils.Equals(t, "/tmp/test.log", config.LogPath)
+
+	bs, err := yaml.Marshal(config)
+	testutils.Ok(t, err)
+	var decoded RotateLogsConfig
+	testutils.Ok(t, yaml.Unmarshal(bs, &decoded))
+	testutils.Equals(t, config, decoded)
+
+	testutils.NotOk(t, yaml.Unmarshal([]byte("format: xml\n"), &config))
+	testutils.NotOk(t, json.Unmarshal([]byte(`{"format": "xml"}`), &config))
+
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	l := logrus.New()
+	out := &bytes.Buffer{}
+	l.SetOutput(out)
+	testutils.Ok(t, AddRotateLogsHook(l, &RotateLogsConfig{LogPath: logPath, RotateMode: RotateModeDay, Format: FormatJSON}))
+
+	l.WithField("id", 1).Info("hooked")
+	testutils.Assert(t, strings.Contains(out.String(), `msg=hooked`), "output keeps text format: %s", out.String())
+
+	data, err := os.ReadFile(logPath)
+	testutils.Ok(t, err)
+	var entry map[string]any
+	testutils.Ok(t, json.Unmarshal(data, &entry))
+	testutils.Equals(t, "hooked", entry["msg"])
+	testutils.Equals(t, float64(1), entry["id"])
+
+	testutils.NotOk(t, SetupRotateLogsLogger(logrus.New(), &RotateLogsConfig{LogPath: logPath, Format: "xml"}))
+}
@@ -0,0 +1,329 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xorm.io/xorm/log"
+)
+
+// RootLevel is the name of the default level, the named loggers without their own levels follow it
+const RootLevel = "root"
+
+// ParseLevel parses the level names: debug, info, warn(warning), error(err) and off
+func ParseLevel(s string) (log.LogLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return log.LOG_DEBUG, nil
+	case "info":
+		return log.LOG_INFO, nil
+	case "warn", "warning":
+		return log.LOG_WARNING, nil
+	case "error", "err":
+		return log.LOG_ERR, nil
+	case "off":
+		return log.LOG_OFF, nil
+	default:
+		return log.LOG_UNKNOWN, fmt.Errorf("unknown log level: %s", s)
+	}
+}
+
+// LevelName returns the name of level parsed by ParseLevel
+func LevelName(l log.LogLevel) string {
+	switch l {
+	case log.LOG_DEBUG:
+		return "debug"
+	case log.LOG_INFO:
+		return "info"
+	case log.LOG_WARNING:
+		return "warn"
+	case log.LOG_ERR:
+		return "error"
+	case log.LOG_OFF:
+		return "off"
+	default:
+		return "unknown"
+	}
+}
+
+// Levels manages the levels of named loggers on a base logger
+type Levels struct {
+	base Logger
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]*levelEntry
+}
+
+type levelEntry struct {
+	level   log.LogLevel
+	inherit bool
+	restore *levelRestore
+}
+
+// levelRestore restores the level when the TTL expires
+type levelRestore struct {
+	timer   *time.Timer
+	level   log.LogLevel
+	inherit bool
+	at      time.Time
+}
+
+// LevelInfo is the level of a named logger
+type LevelInfo struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	// Inherited is true if the logger follows the root level
+	Inherited bool `json:"inherited,omitempty"`
+	// RestoreAt is the time of restoring the level changed with TTL
+	RestoreAt *time.Time `json:"restore_at,omitempty"`
+}
+
+// NewLevels creates the levels with the current level of base as the root level, the level of base is not changed.
+// base still filters the logs by its own level, so set base to log.LOG_DEBUG and the root level by SetLevel
+// to let the named loggers log below the root level.
+func NewLevels(base Logger) *Levels {
+	if base == nil {
+		base = Noop()
+	}
+
+	p := &Levels{
+		base: base,
+		now:  time.Now,
+		entries: map[string]*levelEntry{
+			RootLevel: {level: base.Level()},
+		},
+	}
+	return p
+}
+
+// Logger returns the named logger with the field "logger"=name, RootLevel is the base logger without the field
+func (p *Levels) Logger(name string) Logger {
+	p.mu.Lock()
+	if _, ok := p.entries[name]; !ok {
+		p.entries[name] = &levelEntry{inherit: true}
+	}
+	p.mu.Unlock()
+
+	l := p.base
+	if name != RootLevel {
+		l = l.With("logger", name)
+	}
+	return &levelLogger{levels: p, name: name, logger: l}
+}
+
+// Level returns the level of the named logger
+func (p *Levels) Level(name string) log.LogLevel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.level(name)
+}
+
+func (p *Levels) level(name string) log.LogLevel {
+	entry, ok := p.entries[name]
+	if !ok || entry.inherit {
+		return p.entries[RootLevel].level
+	}
+	return entry.level
+}
+
+// SetLevel sets the level of the named logger, the level is restored after ttl if ttl > 0
+func (p *Levels) SetLevel(name string, level log.LogLevel, ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[name]
+	if !ok {
+		entry = &levelEntry{inherit: true}
+		p.entries[name] = entry
+	}
+
+	restore := entry.restore
+	if restore != nil {
+		restore.timer.Stop()
+		entry.restore = nil
+	}
+
+	if ttl > 0 {
+		// keep restoring to the level before the first change with TTL
+		if restore == nil {
+			restore = &levelRestore{level: entry.level, inherit: entry.inherit}
+		}
+		restore.at = p.now().Add(ttl)
+		restore.timer = time.AfterFunc(ttl, func() { p.restore(name, restore) })
+		entry.restore = restore
+	}
+
+	entry.level = level
+	entry.inherit = false
+}
+
+// ResetLevel makes the named logger follow the root level, the root level can not be reset
+func (p *Levels) ResetLevel(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[name]
+	if !ok || name == RootLevel {
+		return
+	}
+	if entry.restore != nil {
+		entry.restore.timer.Stop()
+		entry.restore = nil
+	}
+	entry.inherit = true
+}
+
+func (p *Levels) restore(name string, restore *levelRestore) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := p.entries[name]
+	// the level is changed again after the timer fired
+	if entry.restore != restore {
+		return
+	}
+	entry.level = restore.level
+	entry.inherit = restore.inherit
+	entry.restore = nil
+}
+
+// List returns the levels of the named loggers sorted by names, the root level is the first
+func (p *Levels) List() []LevelInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	infos := make([]LevelInfo, 0, len(p.entries))
+	for name, entry := range p.entries {
+		info := LevelInfo{Name: name, Level: LevelName(p.level(name)), Inherited: entry.inherit}
+		if entry.restore != nil {
+			at := entry.restore.at
+			info.RestoreAt = &at
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name == RootLevel || infos[j].Name == RootLevel {
+			return infos[i].Name == RootLevel
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+func (p *Levels) enabled(name string, level log.LogLevel) bool {
+	current := p.Level(name)
+	return current != log.LOG_OFF && level >= current
+}
+
+// levelLogger filters the logs by the level of its name
+type levelLogger struct {
+	levels *Levels
+	name   string
+	logger Logger
+}
+
+func (p *levelLogger) With(kvs ...any) Logger {
+	return &levelLogger{levels: p.levels, name: p.name, logger: p.logger.With(kvs...)}
+}
+
+func (p *levelLogger) WithContext(ctx context.Context) Logger {
+	return &levelLogger{levels: p.levels, name: p.name, logger: p.logger.WithContext(ctx)}
+}
+
+func (p *levelLogger) Log(kvs ...any) error {
+	p.Info(kvs...)
+	return nil
+}
+
+func (p *levelLogger) Debug(kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_DEBUG) {
+		p.logger.Debug(kvs...)
+	}
+}
+
+func (p *levelLogger) Debugf(msg string, kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_DEBUG) {
+		p.logger.Debugf(msg, kvs...)
+	}
+}
+
+func (p *levelLogger) Info(kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_INFO) {
+		p.logger.Info(kvs...)
+	}
+}
+
+func (p *levelLogger) Infof(msg string, kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_INFO) {
+		p.logger.Infof(msg, kvs...)
+	}
+}
+
+func (p *levelLogger) Warn(kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_WARNING) {
+		p.logger.Warn(kvs...)
+	}
+}
+
+func (p *levelLogger) Warnf(msg string, kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_WARNING) {
+		p.logger.Warnf(msg, kvs...)
+	}
+}
+
+func (p *levelLogger) Error(kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_ERR) {
+		p.logger.Error(kvs...)
+	}
+}
+
+func (p *levelLogger) Errorf(msg string, kvs ...any) {
+	if p.levels.enabled(p.name, log.LOG_ERR) {
+		p.logger.Errorf(msg, kvs...)
+	}
+}
+
+func (p *levelLogger) Level() log.LogLevel {
+	return p.levels.Level(p.name)
+}
+
+// SetLevel sets the level of the name permanently
+func (p *levelLogger) SetLevel(l log.LogLevel) {
+	p.levels.SetLevel(p.name, l, 0)
+}
+
+func (p *levelLogger) ShowSQL(show ...bool) {
+	p.logger.ShowSQL(show...)
+}
+
+func (p *levelLogger) IsShowSQL() bool {
+	return p.logger.IsShowSQL()
+}
+
+func (p *levelLogger) Writer() io.Writer {
+	return p.logger.Writer()
+}
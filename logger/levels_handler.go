@@ -0,0 +1,92 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-trellis/common/utils/json"
+)
+
+// LevelRequest is the request body of changing a level
+type LevelRequest struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	// TTL restores the level after the duration parsed by time.ParseDuration, exp: "10m", empty is permanent
+	TTL string `json:"ttl"`
+}
+
+// Handler returns the http.Handler of listing and changing the levels:
+//
+//	GET                  list the levels
+//	PUT or POST {...}    change a level by LevelRequest: {"name": "orm", "level": "debug", "ttl": "10m"}
+//	DELETE ?name=orm     make the logger follow the root level again
+//
+// All methods respond the levels like List. Use gin.WrapH(levels.Handler()) for gin routes.
+func (p *Levels) Handler() http.Handler {
+	return http.HandlerFunc(p.serveHTTP)
+}
+
+func (p *Levels) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req LevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelsError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		if req.Name == "" {
+			writeLevelsError(w, http.StatusBadRequest, fmt.Errorf("name is required"))
+			return
+		}
+		level, err := ParseLevel(req.Level)
+		if err != nil {
+			writeLevelsError(w, http.StatusBadRequest, err)
+			return
+		}
+		var ttl time.Duration
+		if req.TTL != "" {
+			if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+				writeLevelsError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl: %q", req.TTL))
+				return
+			}
+		}
+		p.SetLevel(req.Name, level, ttl)
+	case http.MethodDelete:
+		p.ResetLevel(r.URL.Query().Get("name"))
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		writeLevelsError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	writeLevelsJSON(w, http.StatusOK, p.List())
+}
+
+func writeLevelsError(w http.ResponseWriter, code int, err error) {
+	writeLevelsJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeLevelsJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/testutils"
+	"xorm.io/xorm/log"
+)
+
+func TestLevels(t *testing.T) {
+	base, buf := newBufferLogger(t, FormatLogfmt)
+	base.SetLevel(log.LOG_INFO)
+	testutils.Equals(t, log.LOG_INFO, NewLevels(base).Level(RootLevel))
+	testutils.Equals(t, log.LOG_INFO, base.Level())
+
+	// named loggers can log below the root level only if base allows it
+	base.SetLevel(log.LOG_DEBUG)
+	levels := NewLevels(base)
+	levels.SetLevel(RootLevel, log.LOG_INFO, 0)
+	orm := levels.Logger("orm")
+	httpLogger := levels.Logger("http").With("id", 1)
+	root := levels.Logger(RootLevel)
+
+	orm.SetLevel(log.LOG_DEBUG)
+	orm.Debug("orm debug")
+	httpLogger.Debug("http debug")
+	root.Debug("root debug")
+	httpLogger.Info("http info")
+
+	out := buf.String()
+	testutils.Assert(t, strings.Contains(out, `msg="orm debug" logger=orm`), "output: %s", out)
+	testutils.Assert(t, !strings.Contains(out, "http debug"), "output: %s", out)
+	testutils.Assert(t, !strings.Contains(out, "root debug"), "output: %s", out)
+	testutils.Assert(t, strings.Contains(out, `msg="http info" id=1 logger=http`), "output: %s", out)
+
+	levels.SetLevel(RootLevel, log.LOG_ERR, 0)
+	testutils.Equals(t, log.LOG_ERR, httpLogger.Level())
+	testutils.Equals(t, log.LOG_DEBUG, orm.Level())
+
+	levels.ResetLevel("orm")
+	testutils.Equals(t, log.LOG_ERR, orm.Level())
+
+	levels.SetLevel("orm", log.LOG_OFF, 0)
+	buf.Reset()
+	orm.Error("off")
+	testutils.Equals(t, "", buf.String())
+
+	testutils.Equals(t, []LevelInfo{
+		{Name: RootLevel, Level: "error"},
+		{Name: "http", Level: "error", Inherited: true},
+		{Name: "orm", Level: "off"},
+	}, levels.List())
+}
+
+func TestLevels_TTL(t *testing.T) {
+	levels := NewLevels(Noop())
+	levels.SetLevel(RootLevel, log.LOG_INFO, 0)
+	l := levels.Logger("orm")
+
+	levels.SetLevel("orm", log.LOG_DEBUG, time.Hour)
+	levels.SetLevel("orm", log.LOG_WARNING, 20*time.Millisecond)
+	testutils.Equals(t, log.LOG_WARNING, l.Level())
+	testutils.Assert(t, levels.List()[1].RestoreAt != nil, "restore time should be listed")
+
+	time.Sleep(100 * time.Millisecond)
+	testutils.Equals(t, log.LOG_INFO, l.Level())
+	testutils.Equals(t, LevelInfo{Name: "orm", Level: "info", Inherited: true}, levels.List()[1])
+
+	levels.SetLevel("orm", log.LOG_DEBUG, 20*time.Millisecond)
+	levels.SetLevel("orm", log.LOG_ERR, 0)
+	time.Sleep(100 * time.Millisecond)
+	testutils.Equals(t, log.LOG_ERR, l.Level())
+}
+
+func TestLevels_Handler(t *testing.T) {
+	levels := NewLevels(Noop())
+	levels.SetLevel(RootLevel, log.LOG_INFO, 0)
+	levels.Logger("orm")
+	handler := levels.Handler()
+
+	serve := func(method, target, body string) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := serve(http.MethodGet, "/", "")
+	testutils.Equals(t, http.StatusOK, code)
+	var infos []LevelInfo
+	testutils.Ok(t, json.Unmarshal([]byte(body), &infos))
+	testutils.Equals(t, []LevelInfo{{Name: RootLevel, Level: "info"}, {Name: "orm", Level: "info", Inherited: true}}, infos)
+
+	code, body = serve(http.MethodPut, "/", `{"name": "orm", "level": "debug", "ttl": "10m"}`)
+	testutils.Equals(t, http.StatusOK, code)
+	testutils.Assert(t, strings.Contains(body, `"restore_at"`), "body: %s", body)
+	testutils.Equals(t, log.LOG_DEBUG, levels.Level("orm"))
+
+	code, _ = serve(http.MethodPost, "/", `{"name": "root", "level": "warn"}`)
+	testutils.Equals(t, http.StatusOK, code)
+	testutils.Equals(t, log.LOG_WARNING, levels.Level(RootLevel))
+
+	code, _ = serve(http.MethodDelete, "/?name=orm", "")
+	testutils.Equals(t, http.StatusOK, code)
+	testutils.Equals(t, log.LOG_WARNING, levels.Level("orm"))
+
+	code, body = serve(http.MethodPut, "/", `{"name": "orm", "level": "verbose"}`)
+	testutils.Equals(t, http.StatusBadRequest, code)
+	testutils.Assert(t, strings.Contains(body, "unknown log level: verbose"), "body: %s", body)
+
+	code, _ = serve(http.MethodPut, "/", `{"level": "debug"}`)
+	testutils.Equals(t, http.StatusBadRequest, code)
+
+	// invalid TTLs do not make the change permanent
+	for _, ttl := range []string{`"10 minutes"`, `"-1m"`, `600`} {
+		code, body = serve(http.MethodPut, "/", `{"name": "orm", "level": "error", "ttl": `+ttl+`}`)
+		testutils.Equals(t, http.StatusBadRequest, code)
+		testutils.Assert(t, strings.Contains(body, "error"), "body: %s", body)
+	}
+	testutils.Equals(t, log.LOG_WARNING, levels.Level("orm"))
+
+	code, _ = serve(http.MethodPatch, "/", "")
+	testutils.Equals(t, http.StatusMethodNotAllowed, code)
+}
@@ -18,10 +18,12 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"reflect"
 
+	"github.com/go-trellis/common/middleware/tracing"
 	"github.com/go-trellis/common/utils/json"
 	"xorm.io/xorm/log"
 )
@@ -31,9 +33,27 @@ type Logger interface {
 	log.Logger
 
 	With(kvs ...any) Logger
+	// WithContext creates a child logger with the trace_id and request_id of ctx, nil ctx adds nothing
+	WithContext(ctx context.Context) Logger
 	Writer() io.Writer
 }
 
+// contextFields returns the key/value pairs of trace ID and request ID in ctx, empty IDs are skipped
+func contextFields(ctx context.Context) []any {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var kvs []any
+	if traceID := tracing.TraceIDFromContext(ctx); traceID != "" {
+		kvs = append(kvs, tracing.TraceIDKey, traceID)
+	}
+	if requestID := tracing.RequestIDFromContext(ctx); requestID != "" {
+		kvs = append(kvs, tracing.RequestIDKey, requestID)
+	}
+	return kvs
+}
+
 func toString(v any) string {
 	switch reflect.TypeOf(v).Kind() {
 	case reflect.Ptr, reflect.Struct, reflect.Map:
@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"context"
 	"errors"
 	"io"
 	"maps"
@@ -41,9 +42,27 @@ func NewWithLogrusLogger(l *logrus.Logger) Logger {
 type LogrusLogger struct {
 	logger    *logrus.Logger
 	isShowSQL bool
+
+	// closers are the writers of the sinks
+	closers []io.Closer
+}
+
+// Option sets the options of LogrusLogger
+type Option func(*Options)
+
+// Options are the options of LogrusLogger
+type Options struct {
+	format Format
+}
+
+// OptionFormat sets the output format, it overrides the Format of RotateLogsConfig
+func OptionFormat(format Format) Option {
+	return func(o *Options) {
+		o.format = format
+	}
 }
 
-func NewLogrusLogger() (*LogrusLogger, error) {
+func NewLogrusLogger(opts ...Option) (*LogrusLogger, error) {
 	// Create a null logger if no output is configured
 	nullLogger := logrus.New()
 	nullLogger.SetOutput(io.Discard)
@@ -52,11 +71,15 @@ func NewLogrusLogger() (*LogrusLogger, error) {
 		logger: nullLogger,
 	}
 
+	if err := ll.apply(opts...); err != nil {
+		return nil, err
+	}
+
 	return ll, nil
 }
 
 // NewLogrusLoggerWithRotate creates a new logrus logger with file rotation
-func NewLogrusLoggerWithRotate(config *RotateLogsConfig) (*LogrusLogger, error) {
+func NewLogrusLoggerWithRotate(config *RotateLogsConfig, opts ...Option) (*LogrusLogger, error) {
 	logger := logrus.New()
 
 	if config != nil {
@@ -71,9 +94,35 @@ func NewLogrusLoggerWithRotate(config *RotateLogsConfig) (*LogrusLogger, error)
 		logger: logger,
 	}
 
+	if err := ll.apply(opts...); err != nil {
+		return nil, err
+	}
+
 	return ll, nil
 }
 
+func (p *LogrusLogger) apply(opts ...Option) error {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.format == "" {
+		return nil
+	}
+	return p.SetFormat(options.format)
+}
+
+// SetFormat sets the output format of the logger
+func (p *LogrusLogger) SetFormat(format Format) error {
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return err
+	}
+	p.logger.SetFormatter(formatter)
+	return nil
+}
+
 // SetRotateLogs sets up file rotation for the logger
 func (p *LogrusLogger) SetRotateLogs(config *RotateLogsConfig) error {
 	if p.logger == nil || config == nil {
@@ -93,38 +142,24 @@ func (p *LogrusLogger) AddRotateLogsHook(config *RotateLogsConfig) error {
 
 // With creates a child logger with specified fields
 func (p *LogrusLogger) With(kvs ...any) Logger {
-	lenFields := len(kvs)
-	fields := make(logrus.Fields)
-
-	for i := 0; i < lenFields; i += 2 {
-		k := kvs[i]
-		var v any = errors.New("MISSING VALUE")
-		if i+1 < lenFields {
-			v = kvs[i+1]
-		}
-		fields[toString(k)] = v
-	}
-
 	// Create a wrapper logger that uses WithFields for all log calls
 	return &logrusLoggerWithFields{
 		logger:    p.logger,
-		fields:    fields,
+		fields:    withFields(nil, kvs),
 		isShowSQL: p.isShowSQL,
 	}
 }
 
-// logrusLoggerWithFields wraps a logger with fields applied to all log calls
-type logrusLoggerWithFields struct {
-	logger    *logrus.Logger
-	fields    logrus.Fields
-	isShowSQL bool
+// WithContext creates a child logger with the trace_id and request_id of ctx
+func (p *LogrusLogger) WithContext(ctx context.Context) Logger {
+	return p.With(contextFields(ctx)...)
 }
 
-func (p *logrusLoggerWithFields) With(kvs ...any) Logger {
-	// Merge new fields with existing fields
+// withFields returns a copy of fields with the key/value pairs
+func withFields(fields logrus.Fields, kvs []any) logrus.Fields {
 	lenFields := len(kvs)
-	newFields := make(logrus.Fields)
-	maps.Copy(newFields, p.fields)
+	newFields := make(logrus.Fields, len(fields)+lenFields/2)
+	maps.Copy(newFields, fields)
 
 	for i := 0; i < lenFields; i += 2 {
 		k := kvs[i]
@@ -134,14 +169,29 @@ func (p *logrusLoggerWithFields) With(kvs ...any) Logger {
 		}
 		newFields[toString(k)] = v
 	}
+	return newFields
+}
+
+// logrusLoggerWithFields wraps a logger with fields applied to all log calls
+type logrusLoggerWithFields struct {
+	logger    *logrus.Logger
+	fields    logrus.Fields
+	isShowSQL bool
+}
 
+func (p *logrusLoggerWithFields) With(kvs ...any) Logger {
+	// Merge new fields with existing fields
 	return &logrusLoggerWithFields{
 		logger:    p.logger,
-		fields:    newFields,
+		fields:    withFields(p.fields, kvs),
 		isShowSQL: p.isShowSQL,
 	}
 }
 
+func (p *logrusLoggerWithFields) WithContext(ctx context.Context) Logger {
+	return p.With(contextFields(ctx)...)
+}
+
 func (p *logrusLoggerWithFields) Log(kvs ...any) error {
 	p.logger.WithFields(p.fields).Info(kvs...)
 	return nil
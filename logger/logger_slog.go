@@ -0,0 +1,222 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"xorm.io/xorm/log"
+)
+
+var (
+	_ Logger     = (*SlogLogger)(nil)
+	_ log.Logger = (*SlogLogger)(nil)
+)
+
+// levelOff is the slog level of log.LOG_OFF, no record is logged
+const levelOff = slog.Level(100)
+
+// SlogLogger is the Logger writing records into a slog.Handler
+type SlogLogger struct {
+	handler   slog.Handler
+	level     *slog.LevelVar
+	isShowSQL bool
+}
+
+// NewSlogLogger creates a logger with the handler, the default level is log.LOG_INFO,
+// records are also filtered by the handler.
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	if handler == nil {
+		handler = slog.NewTextHandler(io.Discard, nil)
+	}
+	return &SlogLogger{
+		handler: handler,
+		level:   &slog.LevelVar{},
+	}
+}
+
+// Handler returns the slog handler of the logger
+func (p *SlogLogger) Handler() slog.Handler {
+	return p.handler
+}
+
+// With creates a child logger with specified fields
+func (p *SlogLogger) With(kvs ...any) Logger {
+	attrs := slogAttrs(kvs)
+	if len(attrs) == 0 {
+		return p
+	}
+	return &SlogLogger{
+		handler:   p.handler.WithAttrs(attrs),
+		level:     p.level,
+		isShowSQL: p.isShowSQL,
+	}
+}
+
+// WithContext creates a child logger with the trace_id and request_id of ctx
+func (p *SlogLogger) WithContext(ctx context.Context) Logger {
+	return p.With(contextFields(ctx)...)
+}
+
+// slogAttrs converts key/value pairs to attributes like the fields of LogrusLogger
+func slogAttrs(kvs []any) []slog.Attr {
+	lenFields := len(kvs)
+	attrs := make([]slog.Attr, 0, (lenFields+1)/2)
+	for i := 0; i < lenFields; i += 2 {
+		k := kvs[i]
+		var v any = errors.New("MISSING VALUE")
+		if i+1 < lenFields {
+			v = kvs[i+1]
+		}
+		attrs = append(attrs, slog.Any(toString(k), v))
+	}
+	return attrs
+}
+
+func (p *SlogLogger) log(level slog.Level, msg string) {
+	ctx := context.Background()
+	if level < p.level.Level() || !p.handler.Enabled(ctx, level) {
+		return
+	}
+
+	// skip runtime.Callers, log and the exported method
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	_ = p.handler.Handle(ctx, slog.NewRecord(time.Now(), level, msg, pcs[0]))
+}
+
+// Log prints log with kvs
+func (p *SlogLogger) Log(kvs ...any) error {
+	p.log(slog.LevelInfo, fmt.Sprint(kvs...))
+	return nil
+}
+
+// Debug prints debug information
+func (p *SlogLogger) Debug(kvs ...any) {
+	p.log(slog.LevelDebug, fmt.Sprint(kvs...))
+}
+
+// Debugf format prints debug information
+func (p *SlogLogger) Debugf(msg string, kvs ...any) {
+	p.log(slog.LevelDebug, fmt.Sprintf(msg, kvs...))
+}
+
+// Info prints info information
+func (p *SlogLogger) Info(kvs ...any) {
+	p.log(slog.LevelInfo, fmt.Sprint(kvs...))
+}
+
+// Infof format prints info information
+func (p *SlogLogger) Infof(msg string, kvs ...any) {
+	p.log(slog.LevelInfo, fmt.Sprintf(msg, kvs...))
+}
+
+// Warn prints warn information
+func (p *SlogLogger) Warn(kvs ...any) {
+	p.log(slog.LevelWarn, fmt.Sprint(kvs...))
+}
+
+// Warnf format prints warn information
+func (p *SlogLogger) Warnf(msg string, kvs ...any) {
+	p.log(slog.LevelWarn, fmt.Sprintf(msg, kvs...))
+}
+
+// Error prints error information
+func (p *SlogLogger) Error(kvs ...any) {
+	p.log(slog.LevelError, fmt.Sprint(kvs...))
+}
+
+// Errorf format prints error information
+func (p *SlogLogger) Errorf(msg string, kvs ...any) {
+	p.log(slog.LevelError, fmt.Sprintf(msg, kvs...))
+}
+
+// Level returns current log level
+func (p *SlogLogger) Level() log.LogLevel {
+	return xormLevel(p.level.Level())
+}
+
+// SetLevel sets log level, it is shared with the loggers created by With
+func (p *SlogLogger) SetLevel(l log.LogLevel) {
+	p.level.Set(slogLevel(l))
+}
+
+// ShowSQL sets whether to show SQL
+func (p *SlogLogger) ShowSQL(show ...bool) {
+	p.isShowSQL = len(show) == 0 || show[0]
+}
+
+// IsShowSQL returns whether SQL is shown
+func (p *SlogLogger) IsShowSQL() bool {
+	return p.isShowSQL
+}
+
+// Writer returns a writer logging every written line as an info record
+func (p *SlogLogger) Writer() io.Writer {
+	return &slogWriter{logger: p}
+}
+
+type slogWriter struct {
+	logger *SlogLogger
+}
+
+func (p *slogWriter) Write(bs []byte) (int, error) {
+	for line := range strings.SplitSeq(strings.TrimRight(string(bs), "\n"), "\n") {
+		p.logger.log(slog.LevelInfo, line)
+	}
+	return len(bs), nil
+}
+
+func slogLevel(l log.LogLevel) slog.Level {
+	switch l {
+	case log.LOG_DEBUG:
+		return slog.LevelDebug
+	case log.LOG_INFO:
+		return slog.LevelInfo
+	case log.LOG_WARNING:
+		return slog.LevelWarn
+	case log.LOG_ERR:
+		return slog.LevelError
+	case log.LOG_OFF:
+		return levelOff
+	default:
+		return slog.LevelDebug
+	}
+}
+
+func xormLevel(l slog.Level) log.LogLevel {
+	switch {
+	case l >= levelOff:
+		return log.LOG_OFF
+	case l >= slog.LevelError:
+		return log.LOG_ERR
+	case l >= slog.LevelWarn:
+		return log.LOG_WARNING
+	case l >= slog.LevelInfo:
+		return log.LOG_INFO
+	default:
+		return log.LOG_DEBUG
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/go-trellis/common/middleware/tracing"
+	"github.com/go-trellis/common/utils/testutils"
+	"xorm.io/xorm/log"
+)
+
+func newJSONSlogLogger() (*SlogLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true})
+	return NewSlogLogger(handler), buf
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		testutils.Ok(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSlogLogger(t *testing.T) {
+	l, buf := newJSONSlogLogger()
+	testutils.Equals(t, log.LOG_INFO, l.Level())
+
+	l.Debug("hidden")
+	l.Info("user ", "login")
+	l.With("id", 1, "odd").Warnf("retry %d", 3)
+	l.WithContext(tracing.WithTraceID(context.Background(), "trace-1")).Error("failed")
+
+	entries := decodeLines(t, buf)
+	testutils.Equals(t, 3, len(entries))
+	testutils.Equals(t, "INFO", entries[0]["level"])
+	testutils.Equals(t, "user login", entries[0]["msg"])
+	source := entries[0]["source"].(map[string]any)
+	testutils.Assert(t, strings.HasSuffix(source["file"].(string), "logger_slog_test.go"), "source: %v", source)
+
+	testutils.Equals(t, "retry 3", entries[1]["msg"])
+	testutils.Equals(t, float64(1), entries[1]["id"])
+	testutils.Equals(t, "MISSING VALUE", entries[1]["odd"])
+	testutils.Equals(t, "trace-1", entries[2]["trace_id"])
+
+	buf.Reset()
+	l.SetLevel(log.LOG_DEBUG)
+	l.With("id", 2).Debug("shown")
+	fmt.Fprintln(l.Writer(), "from writer")
+	entries = decodeLines(t, buf)
+	testutils.Equals(t, 2, len(entries))
+	testutils.Equals(t, "DEBUG", entries[0]["level"])
+	testutils.Equals(t, "from writer", entries[1]["msg"])
+
+	buf.Reset()
+	l.SetLevel(log.LOG_OFF)
+	testutils.Equals(t, log.LOG_OFF, l.Level())
+	l.Error("off")
+	testutils.Equals(t, "", buf.String())
+}
+
+func TestSlogHandler(t *testing.T) {
+	l, buf := newBufferLogger(t, FormatJSON)
+	l.SetLevel(log.LOG_INFO)
+	sl := slog.New(NewSlogHandler(l))
+
+	ctx := tracing.WithRequestID(context.Background(), "request-1")
+	sl.DebugContext(ctx, "hidden")
+	sl.With("service", "order").WithGroup("http").InfoContext(ctx, "request",
+		"method", "GET", slog.Group("resp", "status", 200), slog.Group("", "inline", true))
+	sl.Warn("slow", "cost", "2s")
+	sl.Error("failed")
+
+	entries := decodeLines(t, buf)
+	testutils.Equals(t, 3, len(entries))
+	testutils.Equals(t, "request", entries[0]["msg"])
+	testutils.Equals(t, "info", entries[0]["level"])
+	testutils.Equals(t, "order", entries[0]["service"])
+	testutils.Equals(t, "GET", entries[0]["http.method"])
+	testutils.Equals(t, float64(200), entries[0]["http.resp.status"])
+	testutils.Equals(t, true, entries[0]["http.inline"])
+	testutils.Equals(t, "request-1", entries[0]["request_id"])
+	testutils.Equals(t, "warning", entries[1]["level"])
+	testutils.Equals(t, "2s", entries[1]["cost"])
+	testutils.Equals(t, "error", entries[2]["level"])
+
+	testutils.Assert(t, !NewSlogHandler(Noop()).Enabled(context.Background(), slog.LevelDebug-1), "lower level should be disabled")
+}
@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"context"
 	"io"
 
 	"xorm.io/xorm/log"
@@ -49,6 +50,9 @@ func (*noop) Writer() io.Writer {
 func (*noop) With(...any) Logger {
 	return &noop{}
 }
+func (*noop) WithContext(context.Context) Logger {
+	return &noop{}
+}
 func (p *noop) Level() log.LogLevel {
 	return p.level
 }
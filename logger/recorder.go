@@ -0,0 +1,250 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xorm.io/xorm/log"
+)
+
+var (
+	_ Logger     = (*Recorder)(nil)
+	_ log.Logger = (*Recorder)(nil)
+)
+
+// RecordedEntry is an entry logged into the Recorder
+type RecordedEntry struct {
+	Time    time.Time
+	Level   log.LogLevel
+	Message string
+	// Fields are the fields of With and WithContext
+	Fields map[string]any
+}
+
+// String returns the entry like the logfmt format without the time
+func (p RecordedEntry) String() string {
+	keys := make([]string, 0, len(p.Fields))
+	for k := range p.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "level=%s msg=%q", LevelName(p.Level), p.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, p.Fields[k])
+	}
+	return sb.String()
+}
+
+// recorderStore is the state shared by the Recorder and the loggers created by With
+type recorderStore struct {
+	mu        sync.RWMutex
+	entries   []RecordedEntry
+	level     log.LogLevel
+	isShowSQL bool
+}
+
+// Recorder is the Logger keeping the entries in memory for the assertions of tests, exp:
+//
+//	rec := logger.NewRecorder()
+//	...
+//	testutils.ContainsField(t, rec, "plugin", "sync")
+//
+// The level is log.LOG_DEBUG by default, the entries under the level are not recorded.
+type Recorder struct {
+	store  *recorderStore
+	fields map[string]any
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{store: &recorderStore{level: log.LOG_DEBUG}}
+}
+
+// Entries returns a copy of the recorded entries in order
+func (p *Recorder) Entries() []RecordedEntry {
+	return p.filter(func(RecordedEntry) bool { return true })
+}
+
+// FilterLevel returns the recorded entries of the level
+func (p *Recorder) FilterLevel(level log.LogLevel) []RecordedEntry {
+	return p.filter(func(e RecordedEntry) bool { return e.Level == level })
+}
+
+// FilterMessage returns the recorded entries whose messages contain substr
+func (p *Recorder) FilterMessage(substr string) []RecordedEntry {
+	return p.filter(func(e RecordedEntry) bool { return strings.Contains(e.Message, substr) })
+}
+
+// FilterField returns the recorded entries with the field key equal to value
+func (p *Recorder) FilterField(key string, value any) []RecordedEntry {
+	return p.filter(func(e RecordedEntry) bool {
+		v, ok := e.Fields[key]
+		return ok && reflect.DeepEqual(v, value)
+	})
+}
+
+// ContainsField reports whether any recorded entry has the field key equal to value
+func (p *Recorder) ContainsField(key string, value any) bool {
+	return len(p.FilterField(key, value)) > 0
+}
+
+// Reset removes the recorded entries
+func (p *Recorder) Reset() {
+	p.store.mu.Lock()
+	p.store.entries = nil
+	p.store.mu.Unlock()
+}
+
+// String returns the recorded entries line by line, for the messages of failed assertions
+func (p *Recorder) String() string {
+	entries := p.Entries()
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *Recorder) filter(fn func(RecordedEntry) bool) []RecordedEntry {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	var entries []RecordedEntry
+	for _, e := range p.store.entries {
+		if fn(e) {
+			e.Fields = maps.Clone(e.Fields)
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (p *Recorder) record(level log.LogLevel, msg string) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	if p.store.level == log.LOG_OFF || level < p.store.level {
+		return
+	}
+	p.store.entries = append(p.store.entries, RecordedEntry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  maps.Clone(p.fields),
+	})
+}
+
+// With creates a child logger with specified fields, the entries are recorded into the same recorder
+func (p *Recorder) With(kvs ...any) Logger {
+	return &Recorder{store: p.store, fields: withFields(p.fields, kvs)}
+}
+
+// WithContext creates a child logger with the trace_id and request_id of ctx
+func (p *Recorder) WithContext(ctx context.Context) Logger {
+	return p.With(contextFields(ctx)...)
+}
+
+// Log prints log with kvs
+func (p *Recorder) Log(kvs ...any) error {
+	p.Info(kvs...)
+	return nil
+}
+
+// Debug prints debug information
+func (p *Recorder) Debug(kvs ...any) {
+	p.record(log.LOG_DEBUG, fmt.Sprint(kvs...))
+}
+
+// Debugf format prints debug information
+func (p *Recorder) Debugf(msg string, kvs ...any) {
+	p.record(log.LOG_DEBUG, fmt.Sprintf(msg, kvs...))
+}
+
+// Info prints info information
+func (p *Recorder) Info(kvs ...any) {
+	p.record(log.LOG_INFO, fmt.Sprint(kvs...))
+}
+
+// Infof format prints info information
+func (p *Recorder) Infof(msg string, kvs ...any) {
+	p.record(log.LOG_INFO, fmt.Sprintf(msg, kvs...))
+}
+
+// Warn prints warn information
+func (p *Recorder) Warn(kvs ...any) {
+	p.record(log.LOG_WARNING, fmt.Sprint(kvs...))
+}
+
+// Warnf format prints warn information
+func (p *Recorder) Warnf(msg string, kvs ...any) {
+	p.record(log.LOG_WARNING, fmt.Sprintf(msg, kvs...))
+}
+
+// Error prints error information
+func (p *Recorder) Error(kvs ...any) {
+	p.record(log.LOG_ERR, fmt.Sprint(kvs...))
+}
+
+// Errorf format prints error information
+func (p *Recorder) Errorf(msg string, kvs ...any) {
+	p.record(log.LOG_ERR, fmt.Sprintf(msg, kvs...))
+}
+
+// Level returns current log level
+func (p *Recorder) Level() log.LogLevel {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+	return p.store.level
+}
+
+// SetLevel sets log level
+func (p *Recorder) SetLevel(l log.LogLevel) {
+	p.store.mu.Lock()
+	p.store.level = l
+	p.store.mu.Unlock()
+}
+
+// ShowSQL sets whether to show SQL
+func (p *Recorder) ShowSQL(show ...bool) {
+	p.store.mu.Lock()
+	p.store.isShowSQL = len(show) == 0 || show[0]
+	p.store.mu.Unlock()
+}
+
+// IsShowSQL returns whether SQL is shown
+func (p *Recorder) IsShowSQL() bool {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+	return p.store.isShowSQL
+}
+
+// Writer returns io.Discard, the writes are not recorded
+func (p *Recorder) Writer() io.Writer {
+	return io.Discard
+}
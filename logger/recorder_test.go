@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-trellis/common/middleware/tracing"
+	"github.com/go-trellis/common/utils/testutils"
+	"xorm.io/xorm/log"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	child := rec.With("plugin", "sync")
+
+	rec.Debug("starting")
+	child.Errorf("run %s failed", "sync")
+	child.With("attempt", 2).Warn("retrying")
+	rec.WithContext(tracing.WithTraceID(context.Background(), "trace-1")).Info("done")
+
+	entries := rec.Entries()
+	testutils.Equals(t, 4, len(entries), "entries: %s", rec)
+	testutils.Equals(t, "starting", entries[0].Message)
+	testutils.Equals(t, map[string]any{"plugin": "sync", "attempt": 2}, entries[2].Fields)
+
+	errs := rec.FilterLevel(log.LOG_ERR)
+	testutils.Equals(t, 1, len(errs))
+	testutils.Equals(t, "run sync failed", errs[0].Message)
+	testutils.Equals(t, `level=error msg="run sync failed" plugin=sync`, errs[0].String())
+
+	testutils.ContainsField(t, rec, "plugin", "sync")
+	testutils.ContainsField(t, rec, tracing.TraceIDKey, "trace-1")
+	testutils.Assert(t, !rec.ContainsField("attempt", 3), "entries: %s", rec)
+	testutils.Equals(t, 2, len(rec.FilterField("plugin", "sync")))
+	testutils.Equals(t, 1, len(rec.FilterMessage("retry")))
+
+	// the returned entries are copies
+	entries[2].Fields["attempt"] = 3
+	testutils.Assert(t, !rec.ContainsField("attempt", 3), "entries: %s", rec)
+
+	child.SetLevel(log.LOG_WARNING)
+	rec.Info("skipped")
+	testutils.Equals(t, 4, len(rec.Entries()))
+
+	rec.Reset()
+	testutils.Equals(t, 0, len(rec.Entries()))
+}
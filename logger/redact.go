@@ -0,0 +1,428 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/types"
+	"xorm.io/xorm/log"
+)
+
+var (
+	_ Logger     = (*RedactLogger)(nil)
+	_ log.Logger = (*RedactLogger)(nil)
+)
+
+// DefaultRedactKeys are the default sensitive keys, a key is sensitive if it contains one of them
+// ignoring the case, '_', '-' and '.', exp: "Access-Token", "user.password" and "cardNumber"
+var DefaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "card_number", "cvv",
+}
+
+// maxRedactDepth is the max depth of walking nested values, deeper values are hidden
+const maxRedactDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// RedactOption sets the options of Redactor
+type RedactOption func(*Redactor)
+
+// RedactKeys sets the sensitive keys, it replaces DefaultRedactKeys
+func RedactKeys(keys ...string) RedactOption {
+	return func(p *Redactor) {
+		p.keys = normalizeRedactKeys(keys)
+	}
+}
+
+// RedactPatterns masks the matches of the patterns in string values,
+// exp: regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4}\b`) for card numbers
+func RedactPatterns(patterns ...*regexp.Regexp) RedactOption {
+	return func(p *Redactor) {
+		p.patterns = append(p.patterns, patterns...)
+	}
+}
+
+// Redactor masks the sensitive values with types.Hidden:
+// types.Secret values, the values of sensitive keys and the matches of patterns in strings.
+// Maps, slices and structs are walked, and converted to maps like their JSON if anything is masked.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor with DefaultRedactKeys
+func NewRedactor(opts ...RedactOption) *Redactor {
+	p := &Redactor{keys: normalizeRedactKeys(DefaultRedactKeys)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func normalizeRedactKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = normalizeRedactKey(key); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+	return normalized
+}
+
+func normalizeRedactKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(key))
+}
+
+// SensitiveKey reports whether the key is sensitive
+func (p *Redactor) SensitiveKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	key = normalizeRedactKey(key)
+	for _, k := range p.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks the matches of the patterns in s
+func (p *Redactor) RedactString(s string) string {
+	for _, pattern := range p.patterns {
+		s = pattern.ReplaceAllString(s, types.Hidden)
+	}
+	return s
+}
+
+// Redact returns v with the sensitive values masked, key is the field name of v, empty if none
+func (p *Redactor) Redact(key string, v any) any {
+	redacted, _ := p.redact(key, v, 0)
+	return redacted
+}
+
+// RedactKVs returns a copy of the key/value pairs with the values masked
+func (p *Redactor) RedactKVs(kvs []any) []any {
+	redacted := make([]any, len(kvs))
+	for i := 0; i < len(kvs); i += 2 {
+		redacted[i] = kvs[i]
+		if i+1 < len(kvs) {
+			redacted[i+1] = p.Redact(fmt.Sprint(kvs[i]), kvs[i+1])
+		}
+	}
+	return redacted
+}
+
+func (p *Redactor) redactArgs(args []any) []any {
+	redacted := make([]any, len(args))
+	for i, arg := range args {
+		redacted[i] = p.Redact("", arg)
+	}
+	return redacted
+}
+
+// redact returns the masked v, and whether anything is masked
+func (p *Redactor) redact(key string, v any, depth int) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if p.SensitiveKey(key) {
+		return types.Hidden, true
+	}
+
+	switch t := v.(type) {
+	case types.Secret:
+		return redactSecret(t), true
+	case *types.Secret:
+		if t == nil {
+			return "", true
+		}
+		return redactSecret(*t), true
+	case string:
+		s := p.RedactString(t)
+		return s, s != t
+	case error:
+		s := t.Error()
+		if masked := p.RedactString(s); masked != s {
+			return errors.New(masked), true
+		}
+		return t, false
+	}
+
+	if depth >= maxRedactDepth {
+		return types.Hidden, true
+	}
+	return p.redactValue(reflect.ValueOf(v), depth)
+}
+
+func redactSecret(s types.Secret) string {
+	if s == "" {
+		return ""
+	}
+	return types.Hidden
+}
+
+func (p *Redactor) redactValue(rv reflect.Value, depth int) (any, bool) {
+	v := rv.Interface()
+	// the values marshaled by themselves are not walked, exp: time.Time
+	if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+		return v, false
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return v, false
+		}
+		elem := rv.Elem()
+		if !elem.CanInterface() {
+			return v, false
+		}
+		if redacted, ok := p.redact("", elem.Interface(), depth+1); ok {
+			return redacted, true
+		}
+		return v, false
+	case reflect.String:
+		s := p.RedactString(rv.String())
+		if s != rv.String() {
+			return s, true
+		}
+		return v, false
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		changed := false
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			redacted, ok := p.redact(k, iter.Value().Interface(), depth+1)
+			m[k] = redacted
+			changed = changed || ok
+		}
+		if changed {
+			return m, true
+		}
+		return v, false
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v, false
+		}
+		s := make([]any, rv.Len())
+		changed := false
+		for i := 0; i < rv.Len(); i++ {
+			redacted, ok := p.redact("", rv.Index(i).Interface(), depth+1)
+			s[i] = redacted
+			changed = changed || ok
+		}
+		if changed {
+			return s, true
+		}
+		return v, false
+	case reflect.Struct:
+		m := make(map[string]any, rv.NumField())
+		if p.redactStruct(rv, m, depth) {
+			return m, true
+		}
+		return v, false
+	default:
+		return v, false
+	}
+}
+
+// redactStruct puts the fields of rv into m by their JSON names, and reports whether anything is masked.
+// Only the fields marshaled by JSON are walked: the exported fields and the fields of embedded structs.
+func (p *Redactor) redactStruct(rv reflect.Value, m map[string]any, depth int) bool {
+	changed := false
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		// the fields of embedded structs are promoted like JSON
+		if field.Anonymous && name == "" {
+			embedded := fv
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !embedded.Type().Implements(jsonMarshalerType) {
+				changed = p.redactStruct(embedded, m, depth+1) || changed
+				continue
+			}
+		}
+		if !fv.CanInterface() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		redacted, ok := p.redact(name, fv.Interface(), depth+1)
+		m[name] = redacted
+		changed = changed || ok
+	}
+	return changed
+}
+
+// RedactLogger masks the sensitive values of the fields and the arguments before logging them
+type RedactLogger struct {
+	redactor *Redactor
+	logger   Logger
+}
+
+// NewRedactLogger wraps l with a redactor
+func NewRedactLogger(l Logger, opts ...RedactOption) *RedactLogger {
+	if l == nil {
+		l = Noop()
+	}
+	return &RedactLogger{redactor: NewRedactor(opts...), logger: l}
+}
+
+// Redactor returns the redactor of the logger
+func (p *RedactLogger) Redactor() *Redactor {
+	return p.redactor
+}
+
+// With creates a child logger with specified fields, the values are masked
+func (p *RedactLogger) With(kvs ...any) Logger {
+	return &RedactLogger{redactor: p.redactor, logger: p.logger.With(p.redactor.RedactKVs(kvs)...)}
+}
+
+// WithContext creates a child logger with the trace_id and request_id of ctx
+func (p *RedactLogger) WithContext(ctx context.Context) Logger {
+	return &RedactLogger{redactor: p.redactor, logger: p.logger.WithContext(ctx)}
+}
+
+// Log prints log with the key/value pairs kvs at the info level, the values are masked by their keys like With
+func (p *RedactLogger) Log(kvs ...any) error {
+	if p.enabled(log.LOG_INFO) {
+		p.logger.Info(p.redactor.RedactKVs(kvs)...)
+	}
+	return nil
+}
+
+// enabled checks the level before walking the arguments
+func (p *RedactLogger) enabled(level log.LogLevel) bool {
+	return level >= p.logger.Level()
+}
+
+// Debug prints debug information
+func (p *RedactLogger) Debug(kvs ...any) {
+	if p.enabled(log.LOG_DEBUG) {
+		p.logger.Debug(p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Debugf format prints debug information
+func (p *RedactLogger) Debugf(msg string, kvs ...any) {
+	if p.enabled(log.LOG_DEBUG) {
+		p.logger.Debugf(msg, p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Info prints info information
+func (p *RedactLogger) Info(kvs ...any) {
+	if p.enabled(log.LOG_INFO) {
+		p.logger.Info(p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Infof format prints info information
+func (p *RedactLogger) Infof(msg string, kvs ...any) {
+	if p.enabled(log.LOG_INFO) {
+		p.logger.Infof(msg, p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Warn prints warn information
+func (p *RedactLogger) Warn(kvs ...any) {
+	if p.enabled(log.LOG_WARNING) {
+		p.logger.Warn(p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Warnf format prints warn information
+func (p *RedactLogger) Warnf(msg string, kvs ...any) {
+	if p.enabled(log.LOG_WARNING) {
+		p.logger.Warnf(msg, p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Error prints error information
+func (p *RedactLogger) Error(kvs ...any) {
+	if p.enabled(log.LOG_ERR) {
+		p.logger.Error(p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Errorf format prints error information
+func (p *RedactLogger) Errorf(msg string, kvs ...any) {
+	if p.enabled(log.LOG_ERR) {
+		p.logger.Errorf(msg, p.redactor.redactArgs(kvs)...)
+	}
+}
+
+// Level returns current log level
+func (p *RedactLogger) Level() log.LogLevel {
+	return p.logger.Level()
+}
+
+// SetLevel sets log level
+func (p *RedactLogger) SetLevel(l log.LogLevel) {
+	p.logger.SetLevel(l)
+}
+
+// ShowSQL sets whether to show SQL
+func (p *RedactLogger) ShowSQL(show ...bool) {
+	p.logger.ShowSQL(show...)
+}
+
+// IsShowSQL returns whether SQL is shown
+func (p *RedactLogger) IsShowSQL() bool {
+	return p.logger.IsShowSQL()
+}
+
+// Writer returns the writer of the logger, the writes are not masked
+func (p *RedactLogger) Writer() io.Writer {
+	return p.logger.Writer()
+}
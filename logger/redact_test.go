@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/go-trellis/common/utils/types"
+	"xorm.io/xorm/log"
+)
+
+var cardNumberPattern = regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4}\b`)
+
+type credential struct {
+	User     string       `json:"user"`
+	Password string       `json:"password"`
+	APIKey   types.Secret `json:"key"`
+	Note     string       `json:"note,omitempty"`
+	Ignored  string       `json:"-"`
+}
+
+type account struct {
+	credential
+	ID        int            `json:"id"`
+	Card      *credential    `json:"card"`
+	Headers   map[string]any `json:"headers"`
+	CreatedAt time.Time      `json:"created_at"`
+	internal  string
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(RedactPatterns(cardNumberPattern))
+
+	testutils.Equals(t, types.Hidden, r.Redact("", types.Secret("s3cr3t")))
+	testutils.Equals(t, "", r.Redact("", types.Secret("")))
+	testutils.Equals(t, types.Hidden, r.Redact("Access-Token", "abc"))
+	testutils.Equals(t, types.Hidden, r.Redact("cardNumber", 4111111111111111))
+	testutils.Equals(t, "paid by <hidden>", r.Redact("note", "paid by 4111 1111 1111 1111"))
+	testutils.Equals(t, "card <hidden> declined", r.Redact("", errors.New("card 4111-1111-1111-1111 declined")).(error).Error())
+
+	// nothing masked keeps the value
+	u := user{Name: "henry", Age: 18}
+	testutils.Equals(t, u, r.Redact("user", u))
+
+	created := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	acc := &account{
+		credential: credential{User: "henry", Password: "p@ss", APIKey: "k", Ignored: "x"},
+		ID:         1,
+		Card:       &credential{User: "henry", Note: "4111111111111111"},
+		Headers:    map[string]any{"Authorization": "Bearer abc", "Accept": "*/*"},
+		CreatedAt:  created,
+		internal:   "internal",
+	}
+	bs, err := json.Marshal(r.Redact("account", acc))
+	testutils.Ok(t, err)
+	var out map[string]any
+	testutils.Ok(t, json.Unmarshal(bs, &out))
+	testutils.Equals(t, map[string]any{
+		"user":       "henry",
+		"password":   types.Hidden,
+		"key":        types.Hidden,
+		"id":         float64(1),
+		"card":       map[string]any{"user": "henry", "password": types.Hidden, "key": "", "note": types.Hidden},
+		"headers":    map[string]any{"Authorization": types.Hidden, "Accept": "*/*"},
+		"created_at": "2025-06-13T10:00:00Z",
+	}, out)
+	// the original value is not changed
+	testutils.Equals(t, "p@ss", acc.Password)
+	testutils.Equals(t, "Bearer abc", acc.Headers["Authorization"])
+
+	custom := NewRedactor(RedactKeys("ssn"))
+	testutils.Equals(t, "p@ss", custom.Redact("password", "p@ss"))
+	testutils.Equals(t, types.Hidden, custom.Redact("user_ssn", "123"))
+}
+
+// countingError counts the calls of Error
+type countingError struct {
+	calls int
+}
+
+func (p *countingError) Error() string {
+	p.calls++
+	return "failed"
+}
+
+func TestRedactLogger(t *testing.T) {
+	base, buf := newBufferLogger(t, FormatJSON)
+	l := NewRedactLogger(base, RedactPatterns(cardNumberPattern))
+
+	l.With("token", "abc", "user", credential{User: "henry", Password: "p@ss"}).
+		With("id", 1).
+		Infof("charge %s with %s", types.Secret("s3cr3t"), "4111111111111111")
+
+	var out map[string]any
+	testutils.Ok(t, json.Unmarshal(buf.Bytes(), &out))
+	testutils.Equals(t, "charge <hidden> with <hidden>", out["msg"])
+	testutils.Equals(t, types.Hidden, out["token"])
+	testutils.Equals(t, float64(1), out["id"])
+	testutils.Equals(t, map[string]any{"user": "henry", "password": types.Hidden, "key": ""}, out["user"])
+
+	buf.Reset()
+	testutils.Ok(t, l.Log("password", "hunter2", "user", "henry"))
+	testutils.Assert(t, !strings.Contains(buf.String(), "hunter2"), "output: %s", buf.String())
+	testutils.Assert(t, strings.Contains(buf.String(), "henry"), "output: %s", buf.String())
+
+	buf.Reset()
+	l.Error(types.Secret("s3cr3t"), " leaked")
+	testutils.Assert(t, !strings.Contains(buf.String(), "s3cr3t"), "output: %s", buf.String())
+
+	// the arguments are not walked below the level
+	base.SetLevel(log.LOG_WARNING)
+	err := &countingError{}
+	l.Info(err)
+	l.Debugf("%v", err)
+	testutils.Equals(t, 0, err.calls)
+	l.Warn(err)
+	testutils.Assert(t, err.calls > 0, "the warning should be logged")
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression defines how rotated archives are compressed
+type Compression string
+
+const (
+	// CompressionNone keeps archives uncompressed
+	CompressionNone Compression = ""
+	// CompressionGzip compresses archives into .gz files
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses archives into .zst files
+	CompressionZstd Compression = "zstd"
+)
+
+// DefaultArchiveTemplate is the default path template of archives: LogPath.{period}
+const DefaultArchiveTemplate = "{path}.{period}"
+
+// tmpSuffix is the suffix of archives being compressed
+const tmpSuffix = ".tmp"
+
+// Validate checks the compression is known
+func (c Compression) Validate() error {
+	switch c {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	default:
+		return fmt.Errorf("unknown compression: %s", c)
+	}
+}
+
+// Ext returns the file extension of compressed archives
+func (c Compression) Ext() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+func (c Compression) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, c.Validate()
+	}
+}
+
+func validateArchiveTemplate(template string) error {
+	if template != "" && !strings.Contains(template, "{period}") {
+		return fmt.Errorf("archive_template %q must contain {period}", template)
+	}
+	return nil
+}
+
+// archivePath expands the archive template with period
+func (w *rotatingFileWriter) archivePath(period string) string {
+	return w.expandTemplate(period, func(s string) string { return s })
+}
+
+// archivePattern returns the glob pattern matching all archives, including the indexed and compressed ones
+func (w *rotatingFileWriter) archivePattern() string {
+	return w.expandTemplate("*", escapeGlob) + "*"
+}
+
+func (w *rotatingFileWriter) expandTemplate(period string, quote func(string) string) string {
+	template := w.archiveTemplate
+	if template == "" {
+		template = DefaultArchiveTemplate
+	}
+
+	base := filepath.Base(w.logPath)
+	ext := filepath.Ext(base)
+	return strings.NewReplacer(
+		"{path}", quote(w.logPath),
+		"{dir}", quote(filepath.Dir(w.logPath)),
+		"{base}", quote(base),
+		"{name}", quote(strings.TrimSuffix(base, ext)),
+		"{ext}", quote(ext),
+		"{period}", period,
+	).Replace(template)
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// compressArchive compresses the archive into path + extension and removes it, the modification time is kept for cleanup
+func (w *rotatingFileWriter) compressArchive(path string) (err error) {
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		// the archive waiting for compression is removed by the cleanup of another rotation
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := path + w.compression.Ext()
+	tmp := target + tmpSuffix
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	cw, err := w.compression.newWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(cw, src); err != nil {
+		return err
+	}
+	if err = cw.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// compressAndCleanup runs in the background after rotation
+func (w *rotatingFileWriter) compressAndCleanup(path string) {
+	defer w.wg.Done()
+
+	w.bgMu.Lock()
+	err := w.compressArchive(path)
+	w.bgMu.Unlock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "compress log archive %s: %v\n", path, err)
+	}
+
+	if err := w.cleanup(); err != nil {
+		fmt.Fprintf(os.Stderr, "cleanup log archives of %s: %v\n", w.logPath, err)
+	}
+}
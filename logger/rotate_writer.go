@@ -19,12 +19,13 @@ package logger
 
 import (
 	"fmt"
-	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -39,9 +40,27 @@ type rotatingFileWriter struct {
 	rotationCount uint
 	forceNewFile  bool
 
+	maxTotalSize    int64
+	compression     Compression
+	archiveTemplate string
+
+	reopenCheckInterval time.Duration
+	reopenCheckWrites   uint
+	lastReopenCheck     time.Time
+	writesSinceCheck    uint
+	signals             chan os.Signal
+	stopSignals         chan struct{}
+	stopOnce            sync.Once
+
 	file      *os.File
 	curPeriod string
 	now       func() time.Time
+	// closed is set by Close, the file is not opened again after it
+	closed bool
+
+	// bgMu serializes compression and cleanup of archives, wg waits for the background compression on Close
+	bgMu sync.Mutex
+	wg   sync.WaitGroup
 }
 
 func newRotatingFileWriter(config *RotateLogsConfig) (*rotatingFileWriter, error) {
@@ -51,6 +70,12 @@ func newRotatingFileWriter(config *RotateLogsConfig) (*rotatingFileWriter, error
 	if config.MaxAge > 0 && config.RotationCount > 0 {
 		return nil, fmt.Errorf("max_age and rotation_count cannot both be set")
 	}
+	if err := config.Compression.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateArchiveTemplate(config.ArchiveTemplate); err != nil {
+		return nil, err
+	}
 
 	rotationTime := config.RotationTime
 	switch config.RotateMode {
@@ -68,7 +93,7 @@ func newRotatingFileWriter(config *RotateLogsConfig) (*rotatingFileWriter, error
 		}
 	}
 
-	return &rotatingFileWriter{
+	w := &rotatingFileWriter{
 		logPath:       config.LogPath,
 		mode:          config.RotateMode,
 		rotationTime:  rotationTime,
@@ -76,24 +101,55 @@ func newRotatingFileWriter(config *RotateLogsConfig) (*rotatingFileWriter, error
 		maxAge:        config.MaxAge,
 		rotationCount: config.RotationCount,
 		forceNewFile:  config.ForceNewFile,
-		now:           time.Now,
-	}, nil
+
+		maxTotalSize:    config.MaxTotalSize,
+		compression:     config.Compression,
+		archiveTemplate: config.ArchiveTemplate,
+
+		reopenCheckInterval: config.ReopenCheckInterval,
+		reopenCheckWrites:   config.ReopenCheckWrites,
+
+		now: time.Now,
+	}
+
+	if config.ReopenOnSIGHUP {
+		w.signals = make(chan os.Signal, 1)
+		w.stopSignals = make(chan struct{})
+		signal.Notify(w.signals, syscall.SIGHUP)
+		go w.watchSignals()
+	}
+
+	return w, nil
 }
 
 func (w *rotatingFileWriter) Write(p []byte) (int, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.closed {
+		return 0, os.ErrClosed
+	}
 	if err := w.ensureOpen(); err != nil {
 		return 0, err
 	}
 	return w.file.Write(p)
 }
 
+// Close closes the active file, stops watching SIGHUP and waits for the compression of archives,
+// Write and Reopen return os.ErrClosed after it
 func (w *rotatingFileWriter) Close() error {
+	w.stopOnce.Do(func() {
+		if w.signals != nil {
+			signal.Stop(w.signals)
+			close(w.stopSignals)
+		}
+	})
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.wg.Wait()
 
+	w.closed = true
 	if w.file == nil {
 		return nil
 	}
@@ -124,6 +180,16 @@ func (w *rotatingFileWriter) ensureOpen() error {
 		return w.rotateLocked(period)
 	}
 
+	if w.shouldCheckReopen() {
+		moved, err := w.movedLocked()
+		if err != nil {
+			return err
+		}
+		if moved {
+			return w.reopenLocked()
+		}
+	}
+
 	if w.maxSize > 0 {
 		info, err := w.file.Stat()
 		if err != nil {
@@ -146,7 +212,8 @@ func (w *rotatingFileWriter) rotateLocked(newPeriod string) error {
 		}
 	}
 
-	if err := w.archiveActiveFile(w.curPeriod); err != nil {
+	archived, err := w.archiveActiveFile(w.curPeriod)
+	if err != nil {
 		return err
 	}
 
@@ -156,13 +223,89 @@ func (w *rotatingFileWriter) rotateLocked(newPeriod string) error {
 
 	w.curPeriod = newPeriod
 
-	if err := w.cleanup(); err != nil {
-		return err
+	// the cleanup runs after compression, so that the sizes of compressed archives are counted
+	if archived != "" && w.compression != CompressionNone {
+		w.wg.Add(1)
+		go w.compressAndCleanup(archived)
+		return nil
 	}
 
+	return w.cleanup()
+}
+
+// Reopen closes and reopens LogPath without archiving it
+func (w *rotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reopenLocked()
+}
+
+func (w *rotatingFileWriter) reopenLocked() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	if err := w.openActiveFile(); err != nil {
+		return err
+	}
+	if w.curPeriod == "" {
+		w.curPeriod = w.periodKey(w.now())
+	}
 	return nil
 }
 
+func (w *rotatingFileWriter) watchSignals() {
+	for {
+		select {
+		case <-w.stopSignals:
+			return
+		case <-w.signals:
+			if err := w.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "reopen log file %s: %v\n", w.logPath, err)
+			}
+		}
+	}
+}
+
+// shouldCheckReopen counts the writes, and reports whether it is time to check the external rotation
+func (w *rotatingFileWriter) shouldCheckReopen() bool {
+	if w.reopenCheckWrites == 0 && w.reopenCheckInterval <= 0 {
+		return false
+	}
+
+	w.writesSinceCheck++
+	now := w.now()
+	if (w.reopenCheckWrites > 0 && w.writesSinceCheck >= w.reopenCheckWrites) ||
+		(w.reopenCheckInterval > 0 && now.Sub(w.lastReopenCheck) >= w.reopenCheckInterval) {
+		w.writesSinceCheck = 0
+		w.lastReopenCheck = now
+		return true
+	}
+	return false
+}
+
+// movedLocked reports whether LogPath is moved or removed from the opened file
+func (w *rotatingFileWriter) movedLocked() (bool, error) {
+	info, err := os.Stat(w.logPath)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	opened, err := w.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	return !os.SameFile(info, opened), nil
+}
+
 func (w *rotatingFileWriter) openActiveFile() error {
 	dir := filepath.Dir(w.logPath)
 	if dir != "" && dir != "." {
@@ -179,43 +322,66 @@ func (w *rotatingFileWriter) openActiveFile() error {
 	return nil
 }
 
-func (w *rotatingFileWriter) archiveActiveFile(period string) error {
+// archiveActiveFile renames the active file to the archive of period, and returns the archive name
+func (w *rotatingFileWriter) archiveActiveFile(period string) (string, error) {
 	info, err := os.Stat(w.logPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return "", nil
 		}
-		return err
+		return "", err
 	}
 	if info.IsDir() {
-		return fmt.Errorf("log path %q is a directory", w.logPath)
+		return "", fmt.Errorf("log path %q is a directory", w.logPath)
 	}
 	if info.Size() == 0 {
-		return os.Remove(w.logPath)
+		return "", os.Remove(w.logPath)
 	}
 
 	archiveName, err := w.nextArchiveName(period)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(archiveName), 0o755); err != nil {
+		return "", fmt.Errorf("create archive directory: %w", err)
+	}
+	if err := os.Rename(w.logPath, archiveName); err != nil {
+		return "", err
 	}
-	return os.Rename(w.logPath, archiveName)
+	return archiveName, nil
 }
 
 func (w *rotatingFileWriter) nextArchiveName(period string) (string, error) {
-	base := w.logPath + "." + period
+	base := w.archivePath(period)
 	name := base
 	for i := 0; ; i++ {
 		if i > 0 {
 			name = fmt.Sprintf("%s.%d", base, i)
 		}
-		if _, err := os.Stat(name); os.IsNotExist(err) {
-			return name, nil
-		} else if err != nil {
+		exists, err := fileExists(name)
+		if err != nil {
 			return "", err
 		}
+		if !exists && w.compression != CompressionNone {
+			exists, err = fileExists(name + w.compression.Ext())
+			if err != nil {
+				return "", err
+			}
+		}
+		if !exists {
+			return name, nil
+		}
 	}
 }
 
+func fileExists(name string) (bool, error) {
+	_, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (w *rotatingFileWriter) periodKey(t time.Time) string {
 	base := truncateToRotationTime(t, w.rotationTime)
 	switch w.mode {
@@ -239,33 +405,38 @@ func truncateToRotationTime(t time.Time, rotationTime time.Duration) time.Time {
 }
 
 func (w *rotatingFileWriter) cleanup() error {
-	if w.maxAge <= 0 && w.rotationCount == 0 {
+	if w.maxAge <= 0 && w.rotationCount == 0 && w.maxTotalSize <= 0 {
 		return nil
 	}
 
-	matches, err := filepath.Glob(w.logPath + ".*")
+	w.bgMu.Lock()
+	defer w.bgMu.Unlock()
+
+	matches, err := filepath.Glob(w.archivePattern())
 	if err != nil {
 		return err
 	}
 
 	type archivedFile struct {
 		path    string
+		size    int64
 		modTime time.Time
 	}
 
 	files := make([]archivedFile, 0, len(matches))
 	for _, path := range matches {
-		if strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
+		if path == w.logPath || strings.HasSuffix(path, tmpSuffix) ||
+			strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
 			continue
 		}
 		info, err := os.Stat(path)
 		if err != nil {
 			continue
 		}
-		if info.Mode()&os.ModeSymlink != 0 {
+		if info.Mode()&os.ModeSymlink != 0 || info.IsDir() {
 			continue
 		}
-		files = append(files, archivedFile{path: path, modTime: info.ModTime()})
+		files = append(files, archivedFile{path: path, size: info.Size(), modTime: info.ModTime()})
 	}
 
 	sort.Slice(files, func(i, j int) bool {
@@ -290,6 +461,20 @@ func (w *rotatingFileWriter) cleanup() error {
 		}
 	}
 
+	// keep the newest archives within maxTotalSize
+	if w.maxTotalSize > 0 {
+		var total int64
+		for i := len(files) - 1; i >= 0; i-- {
+			if _, ok := toRemove[files[i].path]; ok {
+				continue
+			}
+			total += files[i].size
+			if total > w.maxTotalSize {
+				toRemove[files[i].path] = struct{}{}
+			}
+		}
+	}
+
 	for path := range toRemove {
 		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 			return err
@@ -299,5 +484,4 @@ func (w *rotatingFileWriter) cleanup() error {
 	return nil
 }
 
-var _ io.Writer = (*rotatingFileWriter)(nil)
-var _ io.Closer = (*rotatingFileWriter)(nil)
+var _ RotateLogsWriter = (*rotatingFileWriter)(nil)
@@ -18,13 +18,18 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package logger
 
 import (
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/go-trellis/common/utils/testutils"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestRotatingFileWriter_StableActiveFile(t *testing.T) {
@@ -122,3 +127,215 @@ func TestNewRotateLogsWriter_MaxAgeAndRotationCountConflict(t *testing.T) {
 	})
 	testutils.NotOk(t, err, "should reject max_age and rotation_count together")
 }
+
+func TestRotatingFileWriter_Compression(t *testing.T) {
+	for _, c := range []struct {
+		compression Compression
+		read        func(io.Reader) (io.Reader, error)
+	}{
+		{compression: CompressionGzip, read: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{compression: CompressionZstd, read: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	} {
+		t.Run(string(c.compression), func(t *testing.T) {
+			logPath := filepath.Join(t.TempDir(), "app.log")
+
+			current := time.Date(2025, 6, 13, 10, 0, 0, 0, time.Local)
+			w, err := newRotatingFileWriter(&RotateLogsConfig{
+				LogPath:     logPath,
+				RotateMode:  RotateModeDay,
+				Compression: c.compression,
+			})
+			testutils.Ok(t, err)
+			w.now = func() time.Time { return current }
+
+			_, err = w.Write([]byte("day one\n"))
+			testutils.Ok(t, err)
+			current = current.Add(24 * time.Hour)
+			_, err = w.Write([]byte("day two\n"))
+			testutils.Ok(t, err)
+			testutils.Ok(t, w.Close())
+
+			_, err = os.Stat(logPath + ".20250613")
+			testutils.Assert(t, os.IsNotExist(err), "uncompressed archive should be removed")
+
+			f, err := os.Open(logPath + ".20250613" + c.compression.Ext())
+			testutils.Ok(t, err)
+			defer f.Close()
+			r, err := c.read(f)
+			testutils.Ok(t, err)
+			data, err := io.ReadAll(r)
+			testutils.Ok(t, err)
+			testutils.Equals(t, "day one\n", string(data))
+		})
+	}
+
+	_, err := NewRotateLogsWriter(&RotateLogsConfig{LogPath: "/tmp/test.log", Compression: "lz4"})
+	testutils.NotOk(t, err)
+}
+
+func TestRotatingFileWriter_MaxTotalSize(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+
+	current := time.Date(2025, 6, 13, 10, 0, 0, 0, time.Local)
+	w, err := newRotatingFileWriter(&RotateLogsConfig{
+		LogPath:      logPath,
+		RotateMode:   RotateModeHour,
+		MaxTotalSize: 25,
+	})
+	testutils.Ok(t, err)
+	w.now = func() time.Time { return current }
+
+	for i := 0; i < 4; i++ {
+		_, err = w.Write([]byte("0123456789\n"))
+		testutils.Ok(t, err)
+		// archives of the same size are ordered by modification time
+		testutils.Ok(t, os.Chtimes(logPath, current, current))
+		current = current.Add(time.Hour)
+	}
+	_, err = w.Write([]byte("active\n"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, w.Close())
+
+	matches, err := filepath.Glob(logPath + ".*")
+	testutils.Ok(t, err)
+	testutils.Equals(t, []string{logPath + ".2025061312", logPath + ".2025061313"}, matches)
+}
+
+func TestRotatingFileWriter_ArchiveTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+
+	current := time.Date(2025, 6, 13, 10, 0, 0, 0, time.Local)
+	w, err := newRotatingFileWriter(&RotateLogsConfig{
+		LogPath:         logPath,
+		RotateMode:      RotateModeDay,
+		RotationCount:   1,
+		Compression:     CompressionGzip,
+		ArchiveTemplate: "{dir}/archive/{name}-{period}{ext}",
+	})
+	testutils.Ok(t, err)
+	w.now = func() time.Time { return current }
+
+	for i := 0; i < 3; i++ {
+		_, err = w.Write([]byte("line\n"))
+		testutils.Ok(t, err)
+		testutils.Ok(t, os.Chtimes(logPath, current, current))
+		current = current.Add(24 * time.Hour)
+	}
+	testutils.Ok(t, w.Close())
+
+	entries, err := os.ReadDir(filepath.Join(tmpDir, "archive"))
+	testutils.Ok(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	testutils.Equals(t, "app-20250614.log.gz", strings.Join(names, ","))
+
+	_, err = NewRotateLogsWriter(&RotateLogsConfig{LogPath: logPath, ArchiveTemplate: "{path}.old"})
+	testutils.NotOk(t, err, "archive template without {period} should be rejected")
+}
+
+func TestRotatingFileWriter_ReopenOnExternalRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+
+	w, err := newRotatingFileWriter(&RotateLogsConfig{
+		LogPath:           logPath,
+		RotateMode:        RotateModeDay,
+		ReopenCheckWrites: 2,
+	})
+	testutils.Ok(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("first\n"))
+	testutils.Ok(t, err)
+	// logrotate moves the file out from under the process
+	testutils.Ok(t, os.Rename(logPath, logPath+".1"))
+
+	// the second write is the first one checked after opening
+	_, err = w.Write([]byte("second\n"))
+	testutils.Ok(t, err)
+	_, err = w.Write([]byte("third\n"))
+	testutils.Ok(t, err)
+
+	moved, err := os.ReadFile(logPath + ".1")
+	testutils.Ok(t, err)
+	testutils.Equals(t, "first\nsecond\n", string(moved))
+	active, err := os.ReadFile(logPath)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "third\n", string(active))
+}
+
+func TestRotatingFileWriter_Reopen(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+
+	writer, err := NewRotateLogsWriter(&RotateLogsConfig{LogPath: logPath, RotateMode: RotateModeDay})
+	testutils.Ok(t, err)
+	defer writer.Close()
+
+	_, err = writer.Write([]byte("first\n"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, os.Rename(logPath, logPath+".1"))
+	testutils.Ok(t, writer.Reopen())
+
+	_, err = writer.Write([]byte("second\n"))
+	testutils.Ok(t, err)
+	active, err := os.ReadFile(logPath)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "second\n", string(active))
+}
+
+func TestRotatingFileWriter_Closed(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+
+	writer, err := NewRotateLogsWriter(&RotateLogsConfig{LogPath: logPath, RotateMode: RotateModeDay})
+	testutils.Ok(t, err)
+
+	_, err = writer.Write([]byte("first\n"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, writer.Close())
+	testutils.Ok(t, os.Rename(logPath, logPath+".1"))
+
+	testutils.Equals(t, os.ErrClosed, writer.Reopen())
+	_, err = writer.Write([]byte("second\n"))
+	testutils.Equals(t, os.ErrClosed, err)
+	testutils.Ok(t, writer.Close())
+
+	exists, err := fileExists(logPath)
+	testutils.Ok(t, err)
+	testutils.Assert(t, !exists, "log file should not be reopened after Close")
+}
+
+func TestRotatingFileWriter_ReopenOnSIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not supported on windows")
+	}
+
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+
+	writer, err := NewRotateLogsWriter(&RotateLogsConfig{LogPath: logPath, RotateMode: RotateModeDay, ReopenOnSIGHUP: true})
+	testutils.Ok(t, err)
+	defer writer.Close()
+
+	_, err = writer.Write([]byte("first\n"))
+	testutils.Ok(t, err)
+	testutils.Ok(t, os.Rename(logPath, logPath+".1"))
+	process, err := os.FindProcess(os.Getpid())
+	testutils.Ok(t, err)
+	testutils.Ok(t, process.Signal(syscall.SIGHUP))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		exists, err := fileExists(logPath)
+		testutils.Ok(t, err)
+		if exists {
+			break
+		}
+		testutils.Assert(t, time.Now().Before(deadline), "log file should be reopened on SIGHUP")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
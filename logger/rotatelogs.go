@@ -72,6 +72,35 @@ type RotateLogsConfig struct {
 	// WriterLevels specifies which log levels should be written to this writer
 	// Format: ["debug", "info", "warn", "error", "fatal", "panic"]
 	WriterLevels []logrus.Level `yaml:"writer_levels" json:"writer_levels"`
+
+	// MaxTotalSize is the maximum total size in bytes of archives (0 means no limit)
+	// The oldest archives are deleted first when it is exceeded
+	MaxTotalSize int64 `yaml:"max_total_size" json:"max_total_size"`
+
+	// Compression compresses archives in the background after rotation: "gzip" or "zstd"
+	Compression Compression `yaml:"compression" json:"compression"`
+
+	// ArchiveTemplate is the path template of archives, default: "{path}.{period}"
+	// Placeholders: {path} is LogPath, {dir}, {base}, {name} and {ext} are the directory, file name,
+	// file name without extension and extension of LogPath, {period} is required
+	// Example: "{dir}/archive/{name}-{period}{ext}"
+	ArchiveTemplate string `yaml:"archive_template" json:"archive_template"`
+
+	// ReopenOnSIGHUP reopens LogPath when the process receives SIGHUP, exp: postrotate of logrotate
+	ReopenOnSIGHUP bool `yaml:"reopen_on_sighup" json:"reopen_on_sighup"`
+
+	// ReopenCheckInterval checks whether LogPath is moved or removed by external rotation
+	// at most once per interval, and reopens it (0 means no check)
+	// Format: "1s", "10s", etc.
+	ReopenCheckInterval time.Duration `yaml:"reopen_check_interval" json:"reopen_check_interval"`
+
+	// ReopenCheckWrites checks whether LogPath is moved or removed by external rotation
+	// every ReopenCheckWrites writes, and reopens it (0 means no check)
+	ReopenCheckWrites uint `yaml:"reopen_check_writes" json:"reopen_check_writes"`
+
+	// Format is the output format of the log file: "text", "json" or "logfmt"
+	// Empty keeps the formatter of the logger
+	Format Format `yaml:"format" json:"format"`
 }
 
 // DefaultRotateLogsConfig returns a default configuration for rotate logs
@@ -87,12 +116,24 @@ func DefaultRotateLogsConfig(logPath string) *RotateLogsConfig {
 	}
 }
 
+// RotateLogsWriter is the writer returned by NewRotateLogsWriter
+type RotateLogsWriter interface {
+	io.WriteCloser
+
+	// Reopen closes and reopens LogPath without archiving it, exp: after it is moved by logrotate
+	Reopen() error
+}
+
 // NewRotateLogsWriter creates a new rotatelogs writer based on the configuration
-func NewRotateLogsWriter(config *RotateLogsConfig) (io.Writer, error) {
+func NewRotateLogsWriter(config *RotateLogsConfig) (RotateLogsWriter, error) {
 	if config == nil {
 		return nil, nil
 	}
-	return newRotatingFileWriter(config)
+	w, err := newRotatingFileWriter(config)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
 // AddRotateLogsHook adds a file rotation hook to the logrus logger
@@ -101,6 +142,11 @@ func AddRotateLogsHook(logger *logrus.Logger, config *RotateLogsConfig) error {
 		return nil
 	}
 
+	formatter, err := configFormatter(config)
+	if err != nil {
+		return err
+	}
+
 	writer, err := NewRotateLogsWriter(config)
 	if err != nil {
 		return err
@@ -111,11 +157,20 @@ func AddRotateLogsHook(logger *logrus.Logger, config *RotateLogsConfig) error {
 		levels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel, logrus.DebugLevel, logrus.TraceLevel}
 	}
 
-	hook := &writerhook.Hook{
-		Writer:    writer,
-		LogLevels: levels,
+	if formatter == nil {
+		logger.AddHook(&writerhook.Hook{
+			Writer:    writer,
+			LogLevels: levels,
+		})
+		return nil
 	}
-	logger.AddHook(hook)
+
+	// the hook writes in its own format, the default output keeps the formatter of logger
+	logger.AddHook(&formatHook{
+		writer:    writer,
+		formatter: formatter,
+		levels:    levels,
+	})
 
 	return nil
 }
@@ -130,16 +185,32 @@ func SetupRotateLogsLogger(logger *logrus.Logger, config *RotateLogsConfig) erro
 		return nil
 	}
 
+	formatter, err := configFormatter(config)
+	if err != nil {
+		return err
+	}
+
 	writer, err := NewRotateLogsWriter(config)
 	if err != nil {
 		return err
 	}
 
 	logger.SetOutput(writer)
+	if formatter != nil {
+		logger.SetFormatter(formatter)
+	}
 
 	return nil
 }
 
+// configFormatter returns nil if the format of config is empty
+func configFormatter(config *RotateLogsConfig) (logrus.Formatter, error) {
+	if config.Format == "" {
+		return nil, nil
+	}
+	return NewFormatter(config.Format)
+}
+
 func parseRotateDuration(s string) (time.Duration, error) {
 	if s == "" {
 		return 0, nil
@@ -205,41 +276,119 @@ func applyRotateLogsConfigFields(r *RotateLogsConfig, maxAge, rotationTime strin
 		r.WriterLevels = levels
 	}
 
+	if err := r.Compression.Validate(); err != nil {
+		return err
+	}
+	if err := validateArchiveTemplate(r.ArchiveTemplate); err != nil {
+		return err
+	}
+	return r.Format.Validate()
+}
+
+// rotateLogsConfigYAML is the YAML document of RotateLogsConfig,
+// yaml.v3 does not allow the inlined fields to be shadowed like encoding/json.
+type rotateLogsConfigYAML struct {
+	LogPath       string     `yaml:"log_path"`
+	RotateMode    RotateMode `yaml:"rotate_mode"`
+	MaxAge        string     `yaml:"max_age"`
+	RotationTime  string     `yaml:"rotation_time"`
+	MaxSize       int64      `yaml:"max_size"`
+	RotationCount uint       `yaml:"rotation_count"`
+	ForceNewFile  bool       `yaml:"force_new_file"`
+	WriterLevels  []string   `yaml:"writer_levels"`
+
+	MaxTotalSize    int64       `yaml:"max_total_size,omitempty"`
+	Compression     Compression `yaml:"compression,omitempty"`
+	ArchiveTemplate string      `yaml:"archive_template,omitempty"`
+
+	ReopenOnSIGHUP      bool   `yaml:"reopen_on_sighup,omitempty"`
+	ReopenCheckInterval string `yaml:"reopen_check_interval,omitempty"`
+	ReopenCheckWrites   uint   `yaml:"reopen_check_writes,omitempty"`
+
+	Format Format `yaml:"format,omitempty"`
+}
+
+func applyReopenCheckInterval(r *RotateLogsConfig, interval string) error {
+	if interval == "" {
+		return nil
+	}
+	duration, err := parseRotateDuration(interval)
+	if err != nil {
+		return fmt.Errorf("invalid reopen_check_interval duration: %s", interval)
+	}
+	r.ReopenCheckInterval = duration
 	return nil
 }
 
+func formatReopenCheckInterval(interval time.Duration) string {
+	if interval == 0 {
+		return ""
+	}
+	return interval.String()
+}
+
 // UnmarshalYAML implements yaml.Unmarshaler for WriterLevels
 func (r *RotateLogsConfig) UnmarshalYAML(unmarshal func(any) error) error {
-	type Alias RotateLogsConfig
-	aux := &struct {
-		MaxAge       string   `yaml:"max_age"`
-		RotationTime string   `yaml:"rotation_time"`
-		WriterLevels []string `yaml:"writer_levels"`
-		*Alias
-	}{
-		Alias: (*Alias)(r),
+	aux := &rotateLogsConfigYAML{
+		LogPath:       r.LogPath,
+		RotateMode:    r.RotateMode,
+		MaxSize:       r.MaxSize,
+		RotationCount: r.RotationCount,
+		ForceNewFile:  r.ForceNewFile,
+
+		MaxTotalSize:    r.MaxTotalSize,
+		Compression:     r.Compression,
+		ArchiveTemplate: r.ArchiveTemplate,
+
+		ReopenOnSIGHUP:    r.ReopenOnSIGHUP,
+		ReopenCheckWrites: r.ReopenCheckWrites,
+
+		Format: r.Format,
 	}
 
 	if err := unmarshal(aux); err != nil {
 		return err
 	}
 
+	r.LogPath = aux.LogPath
+	r.RotateMode = aux.RotateMode
+	r.MaxSize = aux.MaxSize
+	r.RotationCount = aux.RotationCount
+	r.ForceNewFile = aux.ForceNewFile
+	r.MaxTotalSize = aux.MaxTotalSize
+	r.Compression = aux.Compression
+	r.ArchiveTemplate = aux.ArchiveTemplate
+	r.ReopenOnSIGHUP = aux.ReopenOnSIGHUP
+	r.ReopenCheckWrites = aux.ReopenCheckWrites
+	r.Format = aux.Format
+
+	if err := applyReopenCheckInterval(r, aux.ReopenCheckInterval); err != nil {
+		return err
+	}
 	return applyRotateLogsConfigFields(r, aux.MaxAge, aux.RotationTime, aux.WriterLevels)
 }
 
 // MarshalYAML implements yaml.Marshaler for WriterLevels
 func (r RotateLogsConfig) MarshalYAML() (any, error) {
-	type Alias RotateLogsConfig
-	return &struct {
-		MaxAge       string   `yaml:"max_age"`
-		RotationTime string   `yaml:"rotation_time"`
-		WriterLevels []string `yaml:"writer_levels"`
-		*Alias
-	}{
-		MaxAge:       r.MaxAge.String(),
-		RotationTime: r.RotationTime.String(),
-		WriterLevels: formatWriterLevels(r.WriterLevels),
-		Alias:        (*Alias)(&r),
+	return &rotateLogsConfigYAML{
+		LogPath:       r.LogPath,
+		RotateMode:    r.RotateMode,
+		MaxAge:        r.MaxAge.String(),
+		RotationTime:  r.RotationTime.String(),
+		MaxSize:       r.MaxSize,
+		RotationCount: r.RotationCount,
+		ForceNewFile:  r.ForceNewFile,
+		WriterLevels:  formatWriterLevels(r.WriterLevels),
+
+		MaxTotalSize:    r.MaxTotalSize,
+		Compression:     r.Compression,
+		ArchiveTemplate: r.ArchiveTemplate,
+
+		ReopenOnSIGHUP:      r.ReopenOnSIGHUP,
+		ReopenCheckInterval: formatReopenCheckInterval(r.ReopenCheckInterval),
+		ReopenCheckWrites:   r.ReopenCheckWrites,
+
+		Format: r.Format,
 	}, nil
 }
 
@@ -247,9 +396,10 @@ func (r RotateLogsConfig) MarshalYAML() (any, error) {
 func (r *RotateLogsConfig) UnmarshalJSON(data []byte) error {
 	type Alias RotateLogsConfig
 	aux := &struct {
-		MaxAge       string   `json:"max_age"`
-		RotationTime string   `json:"rotation_time"`
-		WriterLevels []string `json:"writer_levels"`
+		MaxAge              string   `json:"max_age"`
+		RotationTime        string   `json:"rotation_time"`
+		WriterLevels        []string `json:"writer_levels"`
+		ReopenCheckInterval string   `json:"reopen_check_interval"`
 		*Alias
 	}{
 		Alias: (*Alias)(r),
@@ -259,6 +409,9 @@ func (r *RotateLogsConfig) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if err := applyReopenCheckInterval(r, aux.ReopenCheckInterval); err != nil {
+		return err
+	}
 	return applyRotateLogsConfigFields(r, aux.MaxAge, aux.RotationTime, aux.WriterLevels)
 }
 
@@ -266,15 +419,17 @@ func (r *RotateLogsConfig) UnmarshalJSON(data []byte) error {
 func (r RotateLogsConfig) MarshalJSON() ([]byte, error) {
 	type Alias RotateLogsConfig
 	aux := &struct {
-		MaxAge       string   `json:"max_age"`
-		RotationTime string   `json:"rotation_time"`
-		WriterLevels []string `json:"writer_levels"`
+		MaxAge              string   `json:"max_age"`
+		RotationTime        string   `json:"rotation_time"`
+		WriterLevels        []string `json:"writer_levels"`
+		ReopenCheckInterval string   `json:"reopen_check_interval,omitempty"`
 		*Alias
 	}{
-		MaxAge:       r.MaxAge.String(),
-		RotationTime: r.RotationTime.String(),
-		WriterLevels: formatWriterLevels(r.WriterLevels),
-		Alias:        (*Alias)(&r),
+		MaxAge:              r.MaxAge.String(),
+		RotationTime:        r.RotationTime.String(),
+		WriterLevels:        formatWriterLevels(r.WriterLevels),
+		ReopenCheckInterval: formatReopenCheckInterval(r.ReopenCheckInterval),
+		Alias:               (*Alias)(&r),
 	}
 
 	return json.Marshal(aux)
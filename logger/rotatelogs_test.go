@@ -26,6 +26,7 @@ import (
 
 	"github.com/go-trellis/common/utils/testutils"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultRotateLogsConfig(t *testing.T) {
@@ -688,3 +689,20 @@ func TestRotateLogsConfig_UnmarshalJSON_MixedCaseLevels(t *testing.T) {
 	testutils.Assert(t, len(config.WriterLevels) == 3, "should have 3 levels")
 }
 
+func TestRotateLogsConfig_Reopen(t *testing.T) {
+	config := &RotateLogsConfig{}
+	testutils.Ok(t, yaml.Unmarshal([]byte("log_path: /tmp/test.log\nreopen_on_sighup: true\nreopen_check_interval: 5s\nreopen_check_writes: 100\n"), config))
+	testutils.Assert(t, config.ReopenOnSIGHUP, "ReopenOnSIGHUP should be true")
+	testutils.Equals(t, 5*time.Second, config.ReopenCheckInterval)
+	testutils.Equals(t, uint(100), config.ReopenCheckWrites)
+
+	data, err := config.MarshalJSON()
+	testutils.Ok(t, err)
+	decoded := &RotateLogsConfig{}
+	testutils.Ok(t, decoded.UnmarshalJSON(data))
+	testutils.Assert(t, decoded.ReopenOnSIGHUP, "ReopenOnSIGHUP should be true")
+	testutils.Equals(t, 5*time.Second, decoded.ReopenCheckInterval)
+	testutils.Equals(t, uint(100), decoded.ReopenCheckWrites)
+
+	testutils.NotOk(t, decoded.UnmarshalJSON([]byte(`{"reopen_check_interval": "soon"}`)))
+}
@@ -0,0 +1,349 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-trellis/common/middleware/ratelimit"
+	"xorm.io/xorm/log"
+)
+
+const (
+	// DefaultSamplingInterval is the default interval of counting the messages
+	DefaultSamplingInterval = time.Second
+	// DefaultSamplingFirst is the default number of messages logged per key per interval
+	DefaultSamplingFirst = 100
+	// DefaultSamplingThereafter logs 1 in DefaultSamplingThereafter messages after the first ones
+	DefaultSamplingThereafter = 100
+	// DefaultSamplingReportInterval is the default interval of reporting the suppressed counts
+	DefaultSamplingReportInterval = time.Minute
+
+	// maxSamplingKeys bounds the counters, the messages of more keys are counted together by samplingOverflow
+	maxSamplingKeys  = 10000
+	samplingOverflow = "<other messages>"
+)
+
+// SamplingOption sets the options of SamplingLogger
+type SamplingOption func(*sampler)
+
+// SamplingInterval sets the interval of counting the messages
+func SamplingInterval(interval time.Duration) SamplingOption {
+	return func(p *sampler) {
+		p.interval = interval
+	}
+}
+
+// SamplingFirst sets the number of messages logged per key per interval
+func SamplingFirst(n int) SamplingOption {
+	return func(p *sampler) {
+		p.first = n
+	}
+}
+
+// SamplingThereafter logs 1 in m messages after the first ones in an interval, 0 drops all of them
+func SamplingThereafter(m int) SamplingOption {
+	return func(p *sampler) {
+		p.thereafter = m
+	}
+}
+
+// SamplingReportInterval sets the interval of reporting the suppressed counts, 0 only reports on Close
+func SamplingReportInterval(interval time.Duration) SamplingOption {
+	return func(p *sampler) {
+		p.reportInterval = interval
+	}
+}
+
+// SamplingLimiter limits the sampled messages by the limiter with the sampling key,
+// exp: ratelimit.NewTokenBucketLimiter(ratelimit.NewConfig(10, time.Second))
+func SamplingLimiter(limiter ratelimit.Limiter) SamplingOption {
+	return func(p *sampler) {
+		p.limiter = limiter
+	}
+}
+
+// sampler is the state shared by the SamplingLogger and the loggers created by With
+type sampler struct {
+	interval       time.Duration
+	first          int
+	thereafter     int
+	reportInterval time.Duration
+	limiter        ratelimit.Limiter
+	now            func() time.Time
+
+	// reporter is the logger of reporting the suppressed counts
+	reporter Logger
+
+	mu       sync.Mutex
+	counters map[samplingKey]*samplingCounter
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+type samplingKey struct {
+	level   log.LogLevel
+	message string
+}
+
+type samplingCounter struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// SamplingLogger logs the first N messages per key per interval and then 1 in M of them,
+// the key is the level and the message, which is the format of Debugf and the like,
+// or the first argument of Debug and the like, so the values formatted into the message do not make new keys.
+//
+// The suppressed counts are reported by warnings periodically.
+type SamplingLogger struct {
+	sampler *sampler
+	logger  Logger
+}
+
+// NewSamplingLogger wraps l with sampling, call Close to stop reporting
+func NewSamplingLogger(l Logger, opts ...SamplingOption) *SamplingLogger {
+	if l == nil {
+		l = Noop()
+	}
+
+	s := &sampler{
+		interval:       DefaultSamplingInterval,
+		first:          DefaultSamplingFirst,
+		thereafter:     DefaultSamplingThereafter,
+		reportInterval: DefaultSamplingReportInterval,
+		now:            time.Now,
+		reporter:       l,
+		counters:       make(map[samplingKey]*samplingCounter),
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.run()
+	return &SamplingLogger{sampler: s, logger: l}
+}
+
+// Close stops reporting and reports the remaining suppressed counts,
+// it closes the loggers created by With too.
+func (p *SamplingLogger) Close() error {
+	p.sampler.closeOnce.Do(func() {
+		close(p.sampler.stop)
+		<-p.sampler.stopped
+		p.sampler.report()
+	})
+	return nil
+}
+
+func (s *sampler) run() {
+	defer close(s.stopped)
+	if s.reportInterval <= 0 {
+		<-s.stop
+		return
+	}
+
+	ticker := time.NewTicker(s.reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.report()
+		}
+	}
+}
+
+// allow counts the message, and reports whether it should be logged
+func (s *sampler) allow(level log.LogLevel, message string) bool {
+	key := samplingKey{level: level, message: message}
+	now := s.now()
+
+	s.mu.Lock()
+	c, ok := s.counters[key]
+	if !ok && len(s.counters) >= maxSamplingKeys {
+		key.message = samplingOverflow
+		c, ok = s.counters[key]
+	}
+	if !ok {
+		c = &samplingCounter{start: now}
+		s.counters[key] = c
+	}
+	if now.Sub(c.start) >= s.interval {
+		c.start = now
+		c.count = 0
+	}
+	c.count++
+
+	allowed := c.count <= s.first || (s.thereafter > 0 && (c.count-s.first)%s.thereafter == 0)
+	if allowed && s.limiter != nil {
+		allowed, _ = s.limiter.Allow(context.Background(), fmt.Sprintf("%d:%s", level, key.message))
+	}
+	if !allowed {
+		c.suppressed++
+	}
+	s.mu.Unlock()
+
+	return allowed
+}
+
+// report logs the suppressed counts, and removes the counters of expired intervals
+func (s *sampler) report() {
+	type suppressed struct {
+		key   samplingKey
+		count int
+	}
+
+	now := s.now()
+	var reports []suppressed
+
+	s.mu.Lock()
+	for key, c := range s.counters {
+		if c.suppressed > 0 {
+			reports = append(reports, suppressed{key: key, count: c.suppressed})
+			c.suppressed = 0
+		}
+		if now.Sub(c.start) >= s.interval {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].key.level != reports[j].key.level {
+			return reports[i].key.level > reports[j].key.level
+		}
+		return reports[i].key.message < reports[j].key.message
+	})
+	for _, r := range reports {
+		s.reporter.With("sampled_level", LevelName(r.key.level), "sampled_message", r.key.message, "suppressed", r.count).
+			Warn("log messages suppressed by sampling")
+	}
+}
+
+// log checks the level before counting the message, and calls fn if the message is sampled
+func (p *SamplingLogger) log(level log.LogLevel, message string, fn func()) {
+	if level < p.logger.Level() {
+		return
+	}
+	if p.sampler.allow(level, message) {
+		fn()
+	}
+}
+
+// samplingMessage returns the sampling message of kvs: the first argument if it's a string, or its type
+func samplingMessage(kvs []any) string {
+	if len(kvs) == 0 {
+		return ""
+	}
+	if s, ok := kvs[0].(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%T", kvs[0])
+}
+
+// With creates a child logger with specified fields, the counters are shared with the parent
+func (p *SamplingLogger) With(kvs ...any) Logger {
+	return &SamplingLogger{sampler: p.sampler, logger: p.logger.With(kvs...)}
+}
+
+// WithContext creates a child logger with the trace_id and request_id of ctx
+func (p *SamplingLogger) WithContext(ctx context.Context) Logger {
+	return &SamplingLogger{sampler: p.sampler, logger: p.logger.WithContext(ctx)}
+}
+
+// Log prints log with kvs
+func (p *SamplingLogger) Log(kvs ...any) error {
+	p.Info(kvs...)
+	return nil
+}
+
+// Debug prints debug information
+func (p *SamplingLogger) Debug(kvs ...any) {
+	p.log(log.LOG_DEBUG, samplingMessage(kvs), func() { p.logger.Debug(kvs...) })
+}
+
+// Debugf format prints debug information
+func (p *SamplingLogger) Debugf(msg string, kvs ...any) {
+	p.log(log.LOG_DEBUG, msg, func() { p.logger.Debugf(msg, kvs...) })
+}
+
+// Info prints info information
+func (p *SamplingLogger) Info(kvs ...any) {
+	p.log(log.LOG_INFO, samplingMessage(kvs), func() { p.logger.Info(kvs...) })
+}
+
+// Infof format prints info information
+func (p *SamplingLogger) Infof(msg string, kvs ...any) {
+	p.log(log.LOG_INFO, msg, func() { p.logger.Infof(msg, kvs...) })
+}
+
+// Warn prints warn information
+func (p *SamplingLogger) Warn(kvs ...any) {
+	p.log(log.LOG_WARNING, samplingMessage(kvs), func() { p.logger.Warn(kvs...) })
+}
+
+// Warnf format prints warn information
+func (p *SamplingLogger) Warnf(msg string, kvs ...any) {
+	p.log(log.LOG_WARNING, msg, func() { p.logger.Warnf(msg, kvs...) })
+}
+
+// Error prints error information
+func (p *SamplingLogger) Error(kvs ...any) {
+	p.log(log.LOG_ERR, samplingMessage(kvs), func() { p.logger.Error(kvs...) })
+}
+
+// Errorf format prints error information
+func (p *SamplingLogger) Errorf(msg string, kvs ...any) {
+	p.log(log.LOG_ERR, msg, func() { p.logger.Errorf(msg, kvs...) })
+}
+
+// Level returns current log level
+func (p *SamplingLogger) Level() log.LogLevel {
+	return p.logger.Level()
+}
+
+// SetLevel sets log level
+func (p *SamplingLogger) SetLevel(l log.LogLevel) {
+	p.logger.SetLevel(l)
+}
+
+// ShowSQL sets whether to show SQL
+func (p *SamplingLogger) ShowSQL(show ...bool) {
+	p.logger.ShowSQL(show...)
+}
+
+// IsShowSQL returns whether SQL is shown
+func (p *SamplingLogger) IsShowSQL() bool {
+	return p.logger.IsShowSQL()
+}
+
+// Writer returns the writer of the logger, the writes are not sampled
+func (p *SamplingLogger) Writer() io.Writer {
+	return p.logger.Writer()
+}
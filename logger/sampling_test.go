@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/middleware/ratelimit"
+	"github.com/go-trellis/common/utils/testutils"
+	"xorm.io/xorm/log"
+)
+
+func TestSamplingLogger(t *testing.T) {
+	base, buf := newBufferLogger(t, FormatLogfmt)
+	base.SetLevel(log.LOG_INFO)
+
+	current := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	sl := NewSamplingLogger(base, SamplingFirst(2), SamplingThereafter(3),
+		SamplingInterval(time.Second), SamplingReportInterval(0))
+	sl.sampler.now = func() time.Time { return current }
+
+	child := sl.With("db", "orders")
+	for i := 0; i < 10; i++ {
+		// the counters are shared with child loggers
+		if i%2 == 0 {
+			sl.Error("connection refused")
+		} else {
+			child.Error("connection refused")
+		}
+		child.Errorf("query %d failed", i)
+		sl.Debug("disabled")
+	}
+	testutils.Equals(t, 4, strings.Count(buf.String(), "connection refused"))
+	testutils.Equals(t, 4, strings.Count(buf.String(), "failed"))
+	testutils.Assert(t, !strings.Contains(buf.String(), "disabled"), "disabled level should not be counted")
+
+	// a new interval logs the first ones again
+	current = current.Add(time.Second)
+	buf.Reset()
+	sl.Error("connection refused")
+	testutils.Equals(t, 1, strings.Count(buf.String(), "connection refused"))
+
+	buf.Reset()
+	testutils.Ok(t, sl.Close())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	testutils.Equals(t, 2, len(lines), "report: %s", buf.String())
+	testutils.Assert(t, strings.HasSuffix(lines[0], `msg="log messages suppressed by sampling" sampled_level=error sampled_message="connection refused" suppressed=6`), "report: %s", lines[0])
+	testutils.Assert(t, strings.HasSuffix(lines[1], `sampled_message="query %d failed" suppressed=6`), "report: %s", lines[1])
+	// the counters of expired intervals are removed
+	testutils.Equals(t, 1, len(sl.sampler.counters))
+}
+
+type countingStringer struct{ calls *int }
+
+func (p countingStringer) String() string {
+	*p.calls++
+	return "value"
+}
+
+func TestSamplingLogger_Keys(t *testing.T) {
+	base, buf := newBufferLogger(t, FormatLogfmt)
+	base.SetLevel(log.LOG_INFO)
+	sl := NewSamplingLogger(base, SamplingFirst(1), SamplingThereafter(0), SamplingReportInterval(0))
+	defer sl.Close()
+
+	// the disabled levels do not format the arguments
+	calls := 0
+	sl.Debug("disabled", countingStringer{calls: &calls})
+	testutils.Equals(t, 0, calls)
+
+	// the values after the first argument do not make new keys
+	for i := 0; i < 10; i++ {
+		sl.Error("request failed: ", i)
+	}
+	testutils.Equals(t, 1, strings.Count(buf.String(), "request failed"))
+	testutils.Equals(t, 1, len(sl.sampler.counters))
+
+	for i := 0; i < maxSamplingKeys+10; i++ {
+		sl.sampler.allow(log.LOG_ERR, strconv.Itoa(i))
+	}
+	testutils.Equals(t, maxSamplingKeys+1, len(sl.sampler.counters))
+}
+
+func TestSamplingLogger_Limiter(t *testing.T) {
+	base, _ := newBufferLogger(t, FormatLogfmt)
+	base.SetLevel(log.LOG_INFO)
+	// the reporter writes in the background
+	buf := newGateWriter()
+	close(buf.gate)
+	base.logger.SetOutput(buf)
+
+	limiter := ratelimit.NewTokenBucketLimiter(&ratelimit.Config{Rate: 1, Period: time.Hour, Burst: 2})
+	sl := NewSamplingLogger(base, SamplingFirst(100), SamplingLimiter(limiter), SamplingReportInterval(10*time.Millisecond))
+	defer sl.Close()
+
+	for i := 0; i < 5; i++ {
+		sl.Warn("slow query")
+	}
+	testutils.Equals(t, 2, strings.Count(buf.String(), `msg="slow query"`))
+
+	time.Sleep(50 * time.Millisecond)
+	testutils.Assert(t, strings.Contains(buf.String(), `sampled_message="slow query" suppressed=3`), "report: %s", buf.String())
+}
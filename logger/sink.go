@@ -0,0 +1,248 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SinkType is the type of the destination of a sink
+type SinkType string
+
+const (
+	// SinkStdout writes into os.Stdout
+	SinkStdout SinkType = "stdout"
+	// SinkStderr writes into os.Stderr
+	SinkStderr SinkType = "stderr"
+	// SinkFile writes into a rotating file by RotateLogsConfig
+	SinkFile SinkType = "file"
+	// SinkSyslog writes into the local syslog over a unix socket, default: /dev/log
+	SinkSyslog SinkType = "syslog"
+	// SinkTCP writes into a TCP address
+	SinkTCP SinkType = "tcp"
+	// SinkUDP writes into a UDP address
+	SinkUDP SinkType = "udp"
+)
+
+// SinksConfig is the config of the logger writing into several sinks, exp:
+//
+//	level: debug
+//	sinks:
+//	  - type: stderr
+//	    format: text
+//	  - type: file
+//	    format: json
+//	    rotate:
+//	      log_path: /var/log/app.log
+//	  - type: file
+//	    min_level: error
+//	    rotate:
+//	      log_path: /var/log/app.error.log
+type SinksConfig struct {
+	// Level is the level of the logger, default: the most verbose min level of the sinks
+	Level string        `yaml:"level" json:"level"`
+	Sinks []*SinkConfig `yaml:"sinks" json:"sinks"`
+}
+
+// SinkConfig is the config of a sink
+type SinkConfig struct {
+	Type SinkType `yaml:"type" json:"type"`
+	// Format is the format of the sink, default: the format of Rotate or FormatText
+	Format Format `yaml:"format" json:"format"`
+	// MinLevel is the least severe level written into the sink, default: info
+	MinLevel string `yaml:"min_level" json:"min_level"`
+	// MaxLevel is the most severe level written into the sink, default: panic
+	MaxLevel string `yaml:"max_level" json:"max_level"`
+
+	// Rotate is the config of SinkFile, its WriterLevels are used if MinLevel and MaxLevel are empty
+	Rotate *RotateLogsConfig `yaml:"rotate" json:"rotate"`
+
+	// Address is the unix socket of SinkSyslog, or host:port of SinkTCP and SinkUDP,
+	// these sinks write in the background and drop the newest logs if their buffer is full
+	Address string `yaml:"address" json:"address"`
+	// Tag is the tag of SinkSyslog, default: the name of the program
+	Tag string `yaml:"tag" json:"tag"`
+}
+
+// ObjectConfig decodes its values into a model, config.Config implements it
+type ObjectConfig interface {
+	ToObject(key string, model any) error
+}
+
+// NewFromConfig creates the logger writing into the sinks of SinksConfig in cfg, exp: cfg.GetValuesConfig("logger")
+func NewFromConfig(cfg ObjectConfig) (*LogrusLogger, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("nil config")
+	}
+	c := &SinksConfig{}
+	if err := cfg.ToObject("", c); err != nil {
+		return nil, err
+	}
+	return NewLogrusLoggerWithSinks(c)
+}
+
+// NewLogrusLoggerWithSinks creates the logger writing into the sinks, one stdout text sink if none is set.
+// Call Close of the logger to close the files and connections of the sinks.
+func NewLogrusLoggerWithSinks(c *SinksConfig) (*LogrusLogger, error) {
+	if c == nil {
+		c = &SinksConfig{}
+	}
+	sinks := c.Sinks
+	if len(sinks) == 0 {
+		sinks = []*SinkConfig{{Type: SinkStdout}}
+	}
+
+	logger := logrus.New()
+	// every sink is a hook with its own formatter
+	logger.SetOutput(io.Discard)
+	ll := &LogrusLogger{logger: logger}
+
+	verbose := logrus.PanicLevel
+	for i, sink := range sinks {
+		if sink == nil {
+			continue
+		}
+		hook, minLevel, err := newSinkHook(sink)
+		if err != nil {
+			_ = ll.Close()
+			return nil, fmt.Errorf("sink %d(%s): %w", i, sink.Type, err)
+		}
+		if closer, ok := hook.writer.(io.Closer); ok {
+			ll.closers = append(ll.closers, closer)
+		}
+		logger.AddHook(hook)
+		verbose = max(verbose, minLevel)
+	}
+
+	if c.Level != "" {
+		level, err := logrus.ParseLevel(strings.ToLower(c.Level))
+		if err != nil {
+			_ = ll.Close()
+			return nil, fmt.Errorf("invalid log level %q: %w", c.Level, err)
+		}
+		verbose = level
+	}
+	logger.SetLevel(verbose)
+
+	return ll, nil
+}
+
+// newSinkHook returns the hook of the sink and the least severe level of the hook
+func newSinkHook(c *SinkConfig) (*formatHook, logrus.Level, error) {
+	format := c.Format
+	if format == "" && c.Rotate != nil {
+		format = c.Rotate.Format
+	}
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	levels, err := c.levels()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	writer, err := newSinkWriter(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &formatHook{writer: writer, formatter: formatter, levels: levels}, levels[len(levels)-1], nil
+}
+
+// levels returns the levels from the most severe to the least severe
+func (p *SinkConfig) levels() ([]logrus.Level, error) {
+	if p.MinLevel == "" && p.MaxLevel == "" && p.Rotate != nil && len(p.Rotate.WriterLevels) > 0 {
+		levels := make([]logrus.Level, 0, len(logrus.AllLevels))
+		for _, level := range logrus.AllLevels {
+			for _, l := range p.Rotate.WriterLevels {
+				if l == level {
+					levels = append(levels, level)
+					break
+				}
+			}
+		}
+		return levels, nil
+	}
+
+	minLevel, maxLevel := logrus.InfoLevel, logrus.PanicLevel
+	var err error
+	if p.MinLevel != "" {
+		if minLevel, err = logrus.ParseLevel(strings.ToLower(p.MinLevel)); err != nil {
+			return nil, fmt.Errorf("invalid min level %q: %w", p.MinLevel, err)
+		}
+	}
+	if p.MaxLevel != "" {
+		if maxLevel, err = logrus.ParseLevel(strings.ToLower(p.MaxLevel)); err != nil {
+			return nil, fmt.Errorf("invalid max level %q: %w", p.MaxLevel, err)
+		}
+	}
+	if minLevel < maxLevel {
+		return nil, fmt.Errorf("min level %s is more severe than max level %s", minLevel, maxLevel)
+	}
+
+	var levels []logrus.Level
+	for _, level := range logrus.AllLevels {
+		if level >= maxLevel && level <= minLevel {
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+func newSinkWriter(c *SinkConfig) (io.Writer, error) {
+	switch c.Type {
+	case SinkStdout:
+		return os.Stdout, nil
+	case SinkStderr:
+		return os.Stderr, nil
+	case SinkFile:
+		if c.Rotate == nil || c.Rotate.LogPath == "" {
+			return nil, fmt.Errorf("rotate.log_path is required")
+		}
+		return NewRotateLogsWriter(c.Rotate)
+	case SinkSyslog:
+		return newSyslogWriter(c.Address, c.Tag)
+	case SinkTCP, SinkUDP:
+		if c.Address == "" {
+			return nil, fmt.Errorf("address is required")
+		}
+		return newAsyncSink(newNetWriter(c.Address, string(c.Type)), string(c.Type)+":"+c.Address)
+	default:
+		return nil, fmt.Errorf("unknown sink type: %s", c.Type)
+	}
+}
+
+// Close closes the files and connections of the sinks
+func (p *LogrusLogger) Close() error {
+	var errs []error
+	for _, closer := range p.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	p.closers = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/config"
+	"github.com/go-trellis/common/utils/testutils"
+	"github.com/sirupsen/logrus"
+	"xorm.io/xorm/log"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	allPath := filepath.Join(tmpDir, "app.log")
+	errPath := filepath.Join(tmpDir, "app.error.log")
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	testutils.Ok(t, err)
+	defer udp.Close()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	testutils.Ok(t, err)
+	defer tcp.Close()
+	tcpLines := make(chan string, 10)
+	go func() {
+		conn, err := tcp.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			tcpLines <- scanner.Text()
+		}
+	}()
+
+	cfg, err := config.NewConfigOptions(config.OptionString(config.ReaderTypeYAML, fmt.Sprintf(`
+logger:
+  level: debug
+  sinks:
+    - type: file
+      format: json
+      min_level: debug
+      rotate:
+        log_path: %s
+    - type: file
+      min_level: error
+      rotate:
+        log_path: %s
+        format: logfmt
+    - type: udp
+      format: logfmt
+      max_level: warn
+      address: %s
+    - type: tcp
+      format: logfmt
+      address: %s
+`, allPath, errPath, udp.LocalAddr(), tcp.Addr())))
+	testutils.Ok(t, err)
+
+	l, err := NewFromConfig(cfg.GetValuesConfig("logger"))
+	testutils.Ok(t, err)
+	testutils.Equals(t, log.LOG_DEBUG, l.Level())
+
+	l.With("id", 1).Debug("debug message")
+	l.Warn("warn message")
+	l.Error("error message")
+	testutils.Ok(t, l.Close())
+
+	all, err := os.ReadFile(allPath)
+	testutils.Ok(t, err)
+	lines := strings.Split(strings.TrimSpace(string(all)), "\n")
+	testutils.Equals(t, 3, len(lines), "all: %s", all)
+	var entry map[string]any
+	testutils.Ok(t, json.Unmarshal([]byte(lines[0]), &entry))
+	testutils.Equals(t, "debug message", entry["msg"])
+	testutils.Equals(t, float64(1), entry["id"])
+
+	errs, err := os.ReadFile(errPath)
+	testutils.Ok(t, err)
+	testutils.Assert(t, strings.HasSuffix(string(errs), "level=error msg=\"error message\"\n") &&
+		strings.Count(string(errs), "\n") == 1, "errors: %s", errs)
+
+	buf := make([]byte, 1024)
+	testutils.Ok(t, udp.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, _, err := udp.ReadFrom(buf)
+	testutils.Ok(t, err)
+	testutils.Assert(t, strings.Contains(string(buf[:n]), `msg="warn message"`), "udp: %s", buf[:n])
+
+	for _, msg := range []string{`msg="warn message"`, `msg="error message"`} {
+		select {
+		case line := <-tcpLines:
+			testutils.Assert(t, strings.Contains(line, msg), "tcp: %s", line)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("tcp sink did not receive %s", msg)
+		}
+	}
+}
+
+func TestNewLogrusLoggerWithSinks_Syslog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported on windows")
+	}
+
+	address := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", address)
+	testutils.Ok(t, err)
+	defer conn.Close()
+
+	l, err := NewLogrusLoggerWithSinks(&SinksConfig{Sinks: []*SinkConfig{
+		{Type: SinkSyslog, Format: FormatLogfmt, Address: address, Tag: "app"},
+	}})
+	testutils.Ok(t, err)
+	defer l.Close()
+	testutils.Equals(t, log.LOG_INFO, l.Level())
+
+	l.Error("disk full")
+
+	buf := make([]byte, 1024)
+	testutils.Ok(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	testutils.Ok(t, err)
+	msg := string(buf[:n])
+	testutils.Assert(t, strings.HasPrefix(msg, "<11>"), "syslog: %s", msg)
+	testutils.Assert(t, strings.Contains(msg, fmt.Sprintf(" app[%d]: ", os.Getpid())), "syslog: %s", msg)
+	testutils.Assert(t, strings.HasSuffix(msg, `level=error msg="disk full"`), "syslog: %s", msg)
+}
+
+func TestNetWriter_Backoff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	testutils.Ok(t, err)
+	address := listener.Addr().String()
+	testutils.Ok(t, listener.Close())
+
+	w := newNetWriter(address, "tcp")
+	_, err = w.Write([]byte("first\n"))
+	testutils.NotOk(t, err)
+	// the address is not dialed again until the backoff expires
+	_, again := w.Write([]byte("second\n"))
+	testutils.Equals(t, err, again)
+	testutils.Equals(t, sinkMinBackoff, w.backoff)
+	testutils.Ok(t, w.Close())
+
+	l, err := NewLogrusLoggerWithSinks(&SinksConfig{Sinks: []*SinkConfig{{Type: SinkTCP, Address: address}}})
+	testutils.Ok(t, err)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		l.Error("unreachable")
+	}
+	testutils.Assert(t, time.Since(start) < time.Second, "logging should not wait for the unreachable sink")
+	testutils.NotOk(t, l.Close())
+}
+
+func TestNewLogrusLoggerWithSinks_Invalid(t *testing.T) {
+	for _, sink := range []*SinkConfig{
+		{Type: "kafka"},
+		{Type: SinkStdout, Format: "xml"},
+		{Type: SinkStdout, MinLevel: "verbose"},
+		{Type: SinkStdout, MinLevel: "error", MaxLevel: "info"},
+		{Type: SinkFile},
+		{Type: SinkTCP},
+	} {
+		_, err := NewLogrusLoggerWithSinks(&SinksConfig{Sinks: []*SinkConfig{sink}})
+		testutils.NotOk(t, err, "sink %+v should be rejected", sink)
+	}
+
+	_, err := NewLogrusLoggerWithSinks(&SinksConfig{Level: "verbose"})
+	testutils.NotOk(t, err)
+
+	sink := &SinkConfig{Type: SinkStdout, MinLevel: "debug", MaxLevel: "warn"}
+	levels, err := sink.levels()
+	testutils.Ok(t, err)
+	testutils.Equals(t, []logrus.Level{logrus.WarnLevel, logrus.InfoLevel, logrus.DebugLevel}, levels)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSyslogAddress is the unix socket of the local syslog
+	DefaultSyslogAddress = "/dev/log"
+
+	// sinkTimeout is the timeout of dialing and writing the connections of sinks
+	sinkTimeout = 5 * time.Second
+	// sinkMinBackoff and sinkMaxBackoff bound the waiting before dialing again after a failed dial
+	sinkMinBackoff = time.Second
+	sinkMaxBackoff = time.Minute
+)
+
+// levelWriter writes the bytes of an entry with its level
+type levelWriter interface {
+	WriteLevel(level logrus.Level, bs []byte) error
+}
+
+// newAsyncSink writes into w in the background and drops the newest writes if the buffer is full,
+// so that a slow or unreachable endpoint does not block the logging calls
+func newAsyncSink(w io.Writer, name string) (*AsyncWriter, error) {
+	return NewAsyncWriter(w, AsyncOverflowPolicy(OverflowDropNewest), AsyncName(name))
+}
+
+// netWriter writes into a TCP, UDP or unix address, it dials the networks in order and waits with backoff
+// before dialing again after a failed dial
+type netWriter struct {
+	networks []string
+	address  string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	network string
+	backoff time.Duration
+	retryAt time.Time
+	dialErr error
+}
+
+func newNetWriter(address string, networks ...string) *netWriter {
+	return &netWriter{networks: networks, address: address}
+}
+
+func (p *netWriter) Write(bs []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	connected := p.conn != nil
+	n, err := p.write(bs)
+	// the connection may be closed by the peer, retry once with a new one,
+	// unless a part of bs is written and retrying would duplicate it
+	if err != nil && connected && n == 0 {
+		n, err = p.write(bs)
+	}
+	return n, err
+}
+
+func (p *netWriter) write(bs []byte) (int, error) {
+	if p.conn == nil {
+		if err := p.dial(); err != nil {
+			return 0, err
+		}
+	}
+
+	size := len(bs)
+	// the stream socket of syslog separates the messages by new lines
+	if p.network == "unix" {
+		bs = append(bs[:size:size], '\n')
+	}
+
+	_ = p.conn.SetWriteDeadline(time.Now().Add(sinkTimeout))
+	n, err := p.conn.Write(bs)
+	if err != nil {
+		_ = p.conn.Close()
+		p.conn = nil
+	}
+	return min(n, size), err
+}
+
+func (p *netWriter) dial() error {
+	now := time.Now()
+	if now.Before(p.retryAt) {
+		return p.dialErr
+	}
+
+	var errs []error
+	for _, network := range p.networks {
+		conn, err := net.DialTimeout(network, p.address, sinkTimeout)
+		if err == nil {
+			p.conn = conn
+			p.network = network
+			p.backoff = 0
+			p.retryAt = time.Time{}
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	p.backoff = min(max(2*p.backoff, sinkMinBackoff), sinkMaxBackoff)
+	p.retryAt = now.Add(p.backoff)
+	p.dialErr = fmt.Errorf("dial %s: %w", p.address, errors.Join(errs...))
+	return p.dialErr
+}
+
+func (p *netWriter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// syslogWriter writes into the local syslog by the RFC 3164 format with the facility user
+type syslogWriter struct {
+	tag    string
+	writer io.WriteCloser
+}
+
+func newSyslogWriter(address, tag string) (*syslogWriter, error) {
+	if address == "" {
+		address = DefaultSyslogAddress
+	}
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	writer, err := newAsyncSink(newNetWriter(address, "unixgram", "unix"), "syslog:"+address)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{tag: tag, writer: writer}, nil
+}
+
+// syslogSeverity returns the syslog severity of the level
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0 // emerg
+	case logrus.FatalLevel:
+		return 2 // crit
+	case logrus.ErrorLevel:
+		return 3 // err
+	case logrus.WarnLevel:
+		return 4 // warning
+	case logrus.InfoLevel:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// Write writes bs with the info severity
+func (p *syslogWriter) Write(bs []byte) (int, error) {
+	if err := p.WriteLevel(logrus.InfoLevel, bs); err != nil {
+		return 0, err
+	}
+	return len(bs), nil
+}
+
+// WriteLevel writes bs with the severity of level
+func (p *syslogWriter) WriteLevel(level logrus.Level, bs []byte) error {
+	const facilityUser = 1
+	msg := fmt.Sprintf("<%d>%s %s[%d]: %s", facilityUser<<3|syslogSeverity(level),
+		time.Now().Format(time.Stamp), p.tag, os.Getpid(), bytes.TrimRight(bs, "\n"))
+	_, err := p.writer.Write([]byte(msg))
+	return err
+}
+
+func (p *syslogWriter) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+// slogHandler is the slog.Handler writing records into a Logger
+type slogHandler struct {
+	logger Logger
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing records into l,
+// attributes in groups are flattened as group.key, the trace_id and request_id of context are added.
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(l)))
+func NewSlogHandler(l Logger) slog.Handler {
+	if l == nil {
+		l = Noop()
+	}
+	return &slogHandler{logger: l}
+}
+
+// Enabled reports whether the level is enabled by the logger
+func (p *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slogLevel(p.logger.Level())
+}
+
+// Handle writes the record into the logger, the time and source of record are decided by the logger
+func (p *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	kvs := make([]any, 0, 2*r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = appendAttr(kvs, p.prefix(), a)
+		return true
+	})
+
+	l := p.logger.WithContext(ctx)
+	if len(kvs) > 0 {
+		l = l.With(kvs...)
+	}
+
+	switch {
+	case r.Level >= slog.LevelError:
+		l.Error(r.Message)
+	case r.Level >= slog.LevelWarn:
+		l.Warn(r.Message)
+	case r.Level >= slog.LevelInfo:
+		l.Info(r.Message)
+	default:
+		l.Debug(r.Message)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler whose logger has the attributes
+func (p *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var kvs []any
+	for _, a := range attrs {
+		kvs = appendAttr(kvs, p.prefix(), a)
+	}
+	if len(kvs) == 0 {
+		return p
+	}
+	return &slogHandler{logger: p.logger.With(kvs...), groups: p.groups}
+}
+
+// WithGroup returns a handler prefixing the keys of attributes with name
+func (p *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return p
+	}
+	return &slogHandler{logger: p.logger, groups: append(slices.Clip(p.groups), name)}
+}
+
+func (p *slogHandler) prefix() string {
+	if len(p.groups) == 0 {
+		return ""
+	}
+	return strings.Join(p.groups, ".") + "."
+}
+
+// appendAttr appends the key/value pairs of attribute, the groups are flattened
+func appendAttr(kvs []any, prefix string, a slog.Attr) []any {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return append(kvs, prefix+a.Key, a.Value.Any())
+	}
+
+	// the attributes of group with empty key are inlined
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		kvs = appendAttr(kvs, prefix, ga)
+	}
+	return kvs
+}
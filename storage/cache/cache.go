@@ -47,4 +47,24 @@ type Cache interface {
 	Members(tab string) ([]any, bool)
 	// SetExpire Set key expire time in the table Tab.
 	SetExpire(tab string, key any, expire time.Duration) bool
+	// Stats Returns the statistics of the table Tab.
+	Stats(tab string) (Stats, bool)
+	// Stop Stops the janitors of the cache and its tables.
+	Stop()
+}
+
+// CacheOptionFunc configure the cache options.
+type CacheOptionFunc func(*CacheOptions)
+
+// CacheOptions configure the cache
+type CacheOptions struct {
+	// JanitorInterval deletes the expired objects of all tables every interval in background, 0 is disabled
+	JanitorInterval time.Duration
+}
+
+// CacheOptionJanitor set the interval of deleting the expired objects of all tables in background
+func CacheOptionJanitor(interval time.Duration) CacheOptionFunc {
+	return func(o *CacheOptions) {
+		o.JanitorInterval = interval
+	}
 }
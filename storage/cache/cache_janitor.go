@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// janitor calls the function every interval in background until Stop
+type janitor struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// startJanitor returns nil if the interval is not positive
+func startJanitor(interval time.Duration, fn func()) *janitor {
+	if interval <= 0 {
+		return nil
+	}
+
+	j := &janitor{stop: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+	return j
+}
+
+// Stop stops the janitor, it is safe to call on nil
+func (p *janitor) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// tableStats counts the Stats of a table, it is safe for concurrent use
+type tableStats struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+func (p *tableStats) lookup(hit bool) {
+	if hit {
+		p.hits.Add(1)
+	} else {
+		p.misses.Add(1)
+	}
+}
+
+func (p *tableStats) removed(reason EvictReason) {
+	switch reason {
+	case EvictReasonEvicted:
+		p.evictions.Add(1)
+	case EvictReasonExpired:
+		p.expirations.Add(1)
+	}
+}
+
+func (p *tableStats) snapshot() Stats {
+	return Stats{
+		Hits:        p.hits.Load(),
+		Misses:      p.misses.Load(),
+		Evictions:   p.evictions.Load(),
+		Expirations: p.expirations.Load(),
+	}
+}
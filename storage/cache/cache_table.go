@@ -29,6 +29,87 @@ const (
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback func(key any, value any)
 
+// EvictReason is the reason of removing a cache entry
+type EvictReason int
+
+const (
+	// EvictReasonEvicted the entry is evicted by the size of the table
+	EvictReasonEvicted EvictReason = iota
+	// EvictReasonExpired the entry is expired
+	EvictReasonExpired
+	// EvictReasonDeleted the entry is deleted by DeleteObject or DeleteObjects
+	EvictReasonDeleted
+)
+
+func (p EvictReason) String() string {
+	switch p {
+	case EvictReasonEvicted:
+		return "evicted"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictReasonCallback is used to get a callback with the reason when a cache entry is removed
+type EvictReasonCallback func(key any, value any, reason EvictReason)
+
+// EvictionPolicy is the policy choosing the entries evicted by the size of a table
+type EvictionPolicy int
+
+const (
+	// EvictionPolicyLRU is the LRU table: it evicts the least recently inserted or updated entry,
+	// lookups take the read lock and do not change the order
+	EvictionPolicyLRU EvictionPolicy = iota
+	// EvictionPolicyStrictLRU evicts the least recently used entry, lookups move the entry to the front
+	EvictionPolicyStrictLRU
+	// EvictionPolicyLFU evicts the least frequently used entry, the least recently used one of the same frequency
+	EvictionPolicyLFU
+	// EvictionPolicyFIFO evicts the first inserted entry, lookups do not change the order
+	EvictionPolicyFIFO
+	// EvictionPolicyTinyLFU is the W-TinyLFU: new entries stay in a small LRU window,
+	// then they are admitted into the segmented LRU of the main space only if they are more frequent than its victim
+	EvictionPolicyTinyLFU
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictionPolicyLRU:
+		return "lru"
+	case EvictionPolicyStrictLRU:
+		return "strict-lru"
+	case EvictionPolicyLFU:
+		return "lfu"
+	case EvictionPolicyFIFO:
+		return "fifo"
+	case EvictionPolicyTinyLFU:
+		return "tinylfu"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats is the statistics of a table
+type Stats struct {
+	// Hits is the number of lookups found the key
+	Hits uint64 `json:"hits"`
+	// Misses is the number of lookups not found the key or found it expired
+	Misses uint64 `json:"misses"`
+	// Evictions is the number of entries evicted by the size of the table
+	Evictions uint64 `json:"evictions"`
+	// Expirations is the number of expired entries removed
+	Expirations uint64 `json:"expirations"`
+}
+
+// peeker looks up the values of key without counting the stats or changing the order of eviction,
+// it is implemented by the tables built by newTableCache
+type peeker interface {
+	peek(key any) ([]any, bool)
+}
+
 // TableCache table manager for k-vs functions
 type TableCache interface {
 	// Insert the object or all of the objects in list.
@@ -49,6 +130,12 @@ type TableCache interface {
 	LookupAll() (map[any][]any, bool)
 	// SetExpire Set Key Expire time
 	SetExpire(key any, expire time.Duration) bool
+	// DeleteExpired Deletes the expired objects, returns the number of them.
+	DeleteExpired() int
+	// Stats Returns the statistics of the table.
+	Stats() Stats
+	// Stop Stops the janitor of the table, the table is still usable.
+	Stop()
 }
 
 // OptionFunc configure cache options.
@@ -59,8 +146,22 @@ type Options struct {
 	ValueMode ValueMode
 
 	Size int
+	// EvictionPolicy is the policy evicting entries if Size is exceeded, default: EvictionPolicyLRU
+	EvictionPolicy EvictionPolicy
+	// Shards is the number of shards of the table, 0 or 1 is not sharded
+	Shards int
 
 	Evict EvictCallback
+	// EvictReason is called with the reason after Evict
+	EvictReason EvictReasonCallback
+
+	// JanitorInterval deletes the expired objects every interval in background, 0 is disabled
+	JanitorInterval time.Duration
+
+	// LoadTTL, RefreshAfter and NegativeTTL are used by LoadingTable
+	LoadTTL      time.Duration
+	RefreshAfter time.Duration
+	NegativeTTL  time.Duration
 }
 
 // OptionValueMode set the values' model
@@ -83,3 +184,24 @@ func OptionEvict(evict EvictCallback) OptionFunc {
 		t.Evict = evict
 	}
 }
+
+// OptionEvictionPolicy set the eviction policy
+func OptionEvictionPolicy(policy EvictionPolicy) OptionFunc {
+	return func(t *Options) {
+		t.EvictionPolicy = policy
+	}
+}
+
+// OptionEvictReason set the evict callback with the reason
+func OptionEvictReason(evict EvictReasonCallback) OptionFunc {
+	return func(t *Options) {
+		t.EvictReason = evict
+	}
+}
+
+// OptionJanitor set the interval of deleting the expired objects in background, call Stop to stop it
+func OptionJanitor(interval time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.JanitorInterval = interval
+	}
+}
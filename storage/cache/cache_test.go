@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cache
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -117,13 +118,12 @@ func TestCache_LookupAll(t *testing.T) {
 	cache.Insert("table1", "key2", "value2")
 
 	all, ok := cache.LookupAll("table1")
-	if ok {
-		testutils.Assert(t, len(all) == 2, "LookupAll should return 2 entries")
-	} else {
-		// LookupAll may return false if no valid entries, but we inserted items
-		// So if it returns false, there might be an issue with the implementation
-		t.Log("LookupAll returned false, which may be unexpected")
-	}
+	testutils.Assert(t, ok, "LookupAll should return true")
+	testutils.Equals(t, map[any][]any{"key1": {"value1"}, "key2": {"value2"}}, all)
+
+	cache.New("table2")
+	_, ok = cache.LookupAll("table2")
+	testutils.Assert(t, !ok, "LookupAll should return false for an empty table")
 }
 
 func TestCache_Delete(t *testing.T) {
@@ -208,3 +208,107 @@ func TestCache_InsertExpire_Expired(t *testing.T) {
 	_, ok := cache.Lookup("table1", "key1")
 	testutils.Assert(t, !ok, "Lookup should return false for expired key")
 }
+
+type evictRecord struct {
+	key    any
+	reason EvictReason
+}
+
+func TestLRU_EvictReasonAndStats(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		records []evictRecord
+	)
+	tab, err := NewTableCache("table1", OptionKeySize(2), OptionEvictReason(func(key, _ any, reason EvictReason) {
+		mu.Lock()
+		records = append(records, evictRecord{key: key, reason: reason})
+		mu.Unlock()
+	}))
+	testutils.Ok(t, err)
+
+	tab.InsertExpire("key1", "value1", time.Millisecond)
+	tab.Insert("key2", "value2")
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := tab.Lookup("key1")
+	testutils.Assert(t, !ok, "key1 should be expired")
+	_, ok = tab.Lookup("key2")
+	testutils.Assert(t, ok, "key2 should exist")
+	_, ok = tab.Lookup("nonexistent")
+	testutils.Assert(t, !ok, "nonexistent should not exist")
+
+	tab.Insert("key3", "value3")
+	tab.Insert("key4", "value4")
+	tab.DeleteObject("key3")
+
+	testutils.Equals(t, []evictRecord{
+		{key: "key1", reason: EvictReasonExpired},
+		{key: "key2", reason: EvictReasonEvicted},
+		{key: "key3", reason: EvictReasonDeleted},
+	}, records)
+	testutils.Equals(t, Stats{Hits: 1, Misses: 2, Evictions: 1, Expirations: 1}, tab.Stats())
+	testutils.Equals(t, "expired", EvictReasonExpired.String())
+}
+
+func TestLRU_Janitor(t *testing.T) {
+	expired := make(chan any, 10)
+	tab, err := NewTableCache("table1", OptionJanitor(10*time.Millisecond),
+		OptionEvictReason(func(key, _ any, reason EvictReason) {
+			if reason == EvictReasonExpired {
+				expired <- key
+			}
+		}))
+	testutils.Ok(t, err)
+	defer tab.Stop()
+
+	// the unlimited table removes the keys never read again
+	tab.InsertExpire("key1", "value1", time.Millisecond)
+	tab.Insert("key2", "value2")
+
+	select {
+	case key := <-expired:
+		testutils.Equals(t, "key1", key)
+	case <-time.After(time.Second):
+		t.Fatal("janitor should delete the expired key")
+	}
+	testutils.Equals(t, uint64(1), tab.Stats().Expirations)
+	testutils.Assert(t, tab.Member("key2"), "key2 should exist")
+}
+
+func TestCache_Janitor(t *testing.T) {
+	cache := New(CacheOptionJanitor(10 * time.Millisecond))
+	defer cache.Stop()
+	testutils.Ok(t, cache.New("table1"))
+	testutils.Ok(t, cache.New("table2"))
+
+	cache.InsertExpire("table1", "key1", "value1", time.Millisecond)
+	cache.InsertExpire("table2", "key1", "value1", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s1, _ := cache.Stats("table1")
+		s2, _ := cache.Stats("table2")
+		if s1.Expirations == 1 && s2.Expirations == 1 {
+			break
+		}
+		testutils.Assert(t, time.Now().Before(deadline), "janitor should delete the expired keys: %+v %+v", s1, s2)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	_, ok := cache.Stats("nonexistent")
+	testutils.Assert(t, !ok, "Stats should return false for nonexistent table")
+}
+
+func TestLRU_SetExpire_MoveToFront(t *testing.T) {
+	tab, err := NewLRU("table1", Options{Size: 2})
+	testutils.Ok(t, err)
+
+	tab.Insert("key1", "value1")
+	tab.Insert("key2", "value2")
+	tab.SetExpire("key1", time.Hour)
+	tab.Insert("key3", "value3")
+
+	testutils.Equals(t, 2, tab.evictList.Len())
+	testutils.Assert(t, tab.Member("key1"), "key1 should exist")
+	testutils.Assert(t, !tab.Member("key2"), "key2 should be evicted")
+}
@@ -23,4 +23,5 @@ import (
 
 var (
 	ErrTableExists = errcode.New("table already exists")
+	ErrNotFound    = errcode.New("not found")
 )
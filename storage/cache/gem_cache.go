@@ -29,13 +29,36 @@ type gemCache struct {
 	sync.RWMutex
 
 	tables map[string]TableCache
+
+	janitor *janitor
 }
 
 // New return cache manager
-func New() Cache {
-	return &gemCache{
+func New(opts ...CacheOptionFunc) Cache {
+	options := CacheOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	c := &gemCache{
 		tables: make(map[string]TableCache),
 	}
+	c.janitor = startJanitor(options.JanitorInterval, c.deleteExpired)
+	return c
+}
+
+// deleteExpired deletes the expired objects of all tables
+func (p *gemCache) deleteExpired() {
+	p.RLock()
+	tables := make([]TableCache, 0, len(p.tables))
+	for _, t := range p.tables {
+		tables = append(tables, t)
+	}
+	p.RUnlock()
+
+	for _, t := range tables {
+		t.DeleteExpired()
+	}
 }
 
 func (p *gemCache) All() []string {
@@ -80,6 +103,7 @@ func (p *gemCache) Delete(tab string) bool {
 
 	tabCache := p.getTable(tab)
 	if tabCache != nil {
+		tabCache.Stop()
 		tabCache.DeleteObjects()
 		delete(p.tables, tab)
 	}
@@ -161,3 +185,21 @@ func (p *gemCache) LookupAll(tab string) (map[any][]any, bool) {
 	}
 	return tabCache.LookupAll()
 }
+
+func (p *gemCache) Stats(tab string) (Stats, bool) {
+	tabCache := p.getTable(tab)
+	if tabCache == nil {
+		return Stats{}, false
+	}
+	return tabCache.Stats(), true
+}
+
+func (p *gemCache) Stop() {
+	p.janitor.Stop()
+
+	p.RLock()
+	defer p.RUnlock()
+	for _, t := range p.tables {
+		t.Stop()
+	}
+}
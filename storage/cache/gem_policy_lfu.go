@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/heap"
+)
+
+// lfuPolicy evicts the least frequently used entry, the least recently used one of the same frequency
+type lfuPolicy struct {
+	size    int
+	tick    uint64
+	entries lfuHeap
+}
+
+func newLFUPolicy(size int) *lfuPolicy {
+	return &lfuPolicy{size: size}
+}
+
+func (p *lfuPolicy) add(e *tableEntry) []*tableEntry {
+	// evict before pushing, or the new entry is always the least frequently used one
+	var victims []*tableEntry
+	if p.size > 0 && p.entries.Len() >= p.size {
+		victims = append(victims, heap.Pop(&p.entries).(*tableEntry))
+	}
+
+	p.tick++
+	e.freq, e.tick = 1, p.tick
+	heap.Push(&p.entries, e)
+	return victims
+}
+
+func (p *lfuPolicy) access(e *tableEntry) {
+	p.tick++
+	e.freq++
+	e.tick = p.tick
+	heap.Fix(&p.entries, e.index)
+}
+
+func (p *lfuPolicy) remove(e *tableEntry) {
+	heap.Remove(&p.entries, e.index)
+}
+
+func (p *lfuPolicy) reset() {
+	p.entries = nil
+}
+
+// lfuHeap is the min heap of the entries ordered by freq and tick
+type lfuHeap []*tableEntry
+
+func (p lfuHeap) Len() int { return len(p) }
+
+func (p lfuHeap) Less(i, j int) bool {
+	if p[i].freq != p[j].freq {
+		return p[i].freq < p[j].freq
+	}
+	return p[i].tick < p[j].tick
+}
+
+func (p lfuHeap) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+	p[i].index = i
+	p[j].index = j
+}
+
+func (p *lfuHeap) Push(x any) {
+	e := x.(*tableEntry)
+	e.index = len(*p)
+	*p = append(*p, e)
+}
+
+func (p *lfuHeap) Pop() any {
+	old := *p
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*p = old[:n-1]
+	return e
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/list"
+)
+
+// lruPolicy evicts the least recently used entry, lookups move the entry to the front
+type lruPolicy struct {
+	size  int
+	queue *list.List
+}
+
+func newLRUPolicy(size int) *lruPolicy {
+	return &lruPolicy{size: size, queue: list.New()}
+}
+
+func (p *lruPolicy) add(e *tableEntry) []*tableEntry {
+	e.element = p.queue.PushFront(e)
+	if p.size <= 0 || p.queue.Len() <= p.size {
+		return nil
+	}
+	return []*tableEntry{p.queue.Remove(p.queue.Back()).(*tableEntry)}
+}
+
+func (p *lruPolicy) access(e *tableEntry) {
+	p.queue.MoveToFront(e.element)
+}
+
+func (p *lruPolicy) remove(e *tableEntry) {
+	p.queue.Remove(e.element)
+}
+
+func (p *lruPolicy) reset() {
+	p.queue.Init()
+}
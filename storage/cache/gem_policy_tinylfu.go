@@ -0,0 +1,210 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+)
+
+// tinyLFUQueue is the list of tinyLFUPolicy holding an entry
+type tinyLFUQueue int
+
+const (
+	tinyLFUWindow tinyLFUQueue = iota
+	tinyLFUProbation
+	tinyLFUProtected
+)
+
+// tinyLFUPolicy is the W-TinyLFU:
+// the window LRU takes 1% of the size, the main space is a segmented LRU of which the protected segment takes 80%.
+// The candidate evicted from the window replaces the victim of the probation segment
+// only if the sketch estimates it more frequent.
+type tinyLFUPolicy struct {
+	size          int
+	windowSize    int
+	mainSize      int
+	protectedSize int
+
+	window    *list.List
+	probation *list.List
+	protected *list.List
+
+	seed   maphash.Seed
+	sketch *countMinSketch
+}
+
+func newTinyLFUPolicy(size int) *tinyLFUPolicy {
+	p := &tinyLFUPolicy{
+		size:      size,
+		window:    list.New(),
+		probation: list.New(),
+		protected: list.New(),
+		seed:      maphash.MakeSeed(),
+		sketch:    newCountMinSketch(size),
+	}
+	if size > 0 {
+		p.windowSize = max(1, size/100)
+		p.mainSize = size - p.windowSize
+		p.protectedSize = p.mainSize * 80 / 100
+	}
+	return p
+}
+
+func (p *tinyLFUPolicy) add(e *tableEntry) []*tableEntry {
+	e.hash = maphash.Comparable(p.seed, e.Key)
+	p.sketch.increment(e.hash)
+
+	p.push(p.window, tinyLFUWindow, e)
+	if p.size <= 0 || p.window.Len() <= p.windowSize {
+		return nil
+	}
+
+	candidate := p.window.Remove(p.window.Back()).(*tableEntry)
+	if p.probation.Len()+p.protected.Len() < p.mainSize {
+		p.push(p.probation, tinyLFUProbation, candidate)
+		return nil
+	}
+
+	victimElement := p.probation.Back()
+	if victimElement == nil {
+		victimElement = p.protected.Back()
+	}
+	if victimElement == nil {
+		return []*tableEntry{candidate}
+	}
+	victim := victimElement.Value.(*tableEntry)
+	if p.sketch.estimate(candidate.hash) <= p.sketch.estimate(victim.hash) {
+		return []*tableEntry{candidate}
+	}
+	p.remove(victim)
+	p.push(p.probation, tinyLFUProbation, candidate)
+	return []*tableEntry{victim}
+}
+
+func (p *tinyLFUPolicy) access(e *tableEntry) {
+	p.sketch.increment(e.hash)
+
+	switch e.queue {
+	case tinyLFUWindow:
+		p.window.MoveToFront(e.element)
+	case tinyLFUProtected:
+		p.protected.MoveToFront(e.element)
+	case tinyLFUProbation:
+		p.probation.Remove(e.element)
+		p.push(p.protected, tinyLFUProtected, e)
+		if p.protected.Len() > p.protectedSize {
+			demoted := p.protected.Remove(p.protected.Back()).(*tableEntry)
+			p.push(p.probation, tinyLFUProbation, demoted)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy) remove(e *tableEntry) {
+	p.list(e.queue).Remove(e.element)
+}
+
+func (p *tinyLFUPolicy) reset() {
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+	p.sketch.reset()
+}
+
+func (p *tinyLFUPolicy) push(l *list.List, queue tinyLFUQueue, e *tableEntry) {
+	e.queue = queue
+	e.element = l.PushFront(e)
+}
+
+func (p *tinyLFUPolicy) list(queue tinyLFUQueue) *list.List {
+	switch queue {
+	case tinyLFUProbation:
+		return p.probation
+	case tinyLFUProtected:
+		return p.protected
+	default:
+		return p.window
+	}
+}
+
+const (
+	sketchDepth   = 4
+	sketchMaxFreq = 15
+)
+
+// countMinSketch estimates the frequencies of the hashes with 4-bit counters, every row has 8 counters per entry.
+// All counters are halved after 10 times of the size of increments to forget the old frequencies.
+type countMinSketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(size int) *countMinSketch {
+	size = max(size, 16)
+	width := 1
+	for width < 8*size {
+		width <<= 1
+	}
+	p := &countMinSketch{mask: uint64(width - 1), sampleSize: 10 * size}
+	for i := range p.rows {
+		p.rows[i] = make([]uint8, width)
+	}
+	return p
+}
+
+func (p *countMinSketch) index(hash uint64, i int) uint64 {
+	return (hash + uint64(i)*(hash>>32|1)) & p.mask
+}
+
+func (p *countMinSketch) increment(hash uint64) {
+	for i := range p.rows {
+		if idx := p.index(hash, i); p.rows[i][idx] < sketchMaxFreq {
+			p.rows[i][idx]++
+		}
+	}
+	p.additions++
+	if p.additions >= p.sampleSize {
+		p.age()
+	}
+}
+
+func (p *countMinSketch) estimate(hash uint64) uint8 {
+	freq := uint8(sketchMaxFreq)
+	for i := range p.rows {
+		freq = min(freq, p.rows[i][p.index(hash, i)])
+	}
+	return freq
+}
+
+func (p *countMinSketch) age() {
+	for i := range p.rows {
+		for j := range p.rows[i] {
+			p.rows[i][j] >>= 1
+		}
+	}
+	p.additions /= 2
+}
+
+func (p *countMinSketch) reset() {
+	for i := range p.rows {
+		clear(p.rows[i])
+	}
+	p.additions = 0
+}
@@ -20,6 +20,7 @@ package cache
 import (
 	"container/list"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -34,22 +35,42 @@ type LRU struct {
 	items     map[any]*list.Element
 	onEvict   EvictCallback
 
+	onEvictReason EvictReasonCallback
+
 	valueMode ValueMode
+
+	stats   tableStats
+	janitor *janitor
 }
 
-// NewTableCache constructs a fixed size cache.
+// NewTableCache constructs a fixed size cache of the eviction policy, it is sharded by OptionShards.
 func NewTableCache(name string, opts ...OptionFunc) (TableCache, error) {
 	options := Options{}
 	for _, o := range opts {
 		o(&options)
 	}
+	return newTableCache(name, options)
+}
 
-	lru, err := NewLRU(name, options)
-	if err != nil {
-		return nil, err
+func newTableCache(name string, opts Options) (TableCache, error) {
+	if opts.Shards > 1 {
+		return newShardedTable(name, opts)
 	}
 
-	return lru, nil
+	switch opts.EvictionPolicy {
+	case EvictionPolicyLRU:
+		return NewLRU(name, opts)
+	case EvictionPolicyStrictLRU:
+		return newPolicyTable(name, opts, newLRUPolicy(opts.Size))
+	case EvictionPolicyLFU:
+		return newPolicyTable(name, opts, newLFUPolicy(opts.Size))
+	case EvictionPolicyFIFO:
+		return newPolicyTable(name, opts, newFIFOPolicy(opts.Size))
+	case EvictionPolicyTinyLFU:
+		return newPolicyTable(name, opts, newTinyLFUPolicy(opts.Size))
+	default:
+		return nil, fmt.Errorf("unknown eviction policy: %d", opts.EvictionPolicy)
+	}
 }
 
 // NewLRU constructs an LRU of the given options
@@ -64,7 +85,10 @@ func NewLRU(name string, opts Options) (*LRU, error) {
 		items:     make(map[any]*list.Element),
 		onEvict:   opts.Evict,
 		valueMode: opts.ValueMode,
+
+		onEvictReason: opts.EvictReason,
 	}
+	c.janitor = startJanitor(opts.JanitorInterval, func() { c.DeleteExpired() })
 	return c, nil
 }
 
@@ -74,7 +98,7 @@ func (p *LRU) DeleteObject(key any) (present bool) {
 	p.locker.Lock()
 	defer p.locker.Unlock()
 	if ent, ok := p.items[key]; ok {
-		p.removeElement(ent)
+		p.removeElement(ent, EvictReasonDeleted)
 		return true
 	}
 	return false
@@ -86,9 +110,7 @@ func (p *LRU) DeleteObjects() {
 	defer p.locker.Unlock()
 
 	for k, v := range p.items {
-		if p.onEvict != nil {
-			p.onEvict(k, v.Value.(*DataValues).Values)
-		}
+		p.evicted(k, v.Value.(*DataValues).Values, EvictReasonDeleted)
 		delete(p.items, k)
 	}
 	p.evictList.Init()
@@ -105,6 +127,7 @@ func (p *LRU) InsertExpire(key, value any, expire time.Duration) bool {
 	entry, ok := p.items[key]
 	if ok {
 		if _, ok := p.isElementExpired(entry); ok {
+			p.evicted(key, entry.Value.(*DataValues).Values, EvictReasonExpired)
 			dv = &DataValues{Key: key, Exists: make(map[any]bool)}
 		} else {
 			dv = entry.Value.(*DataValues)
@@ -163,15 +186,54 @@ func (p *LRU) Lookup(key any) ([]any, bool) {
 		values, ok := p.isElementExpired(entry)
 		p.locker.RUnlock()
 		if ok {
-			go p.DeleteObject(entry)
+			p.deleteExpired(key)
+			p.stats.lookup(false)
 			return nil, false
 		}
+		p.stats.lookup(true)
 		return values, true
 	}
 	p.locker.RUnlock()
+	p.stats.lookup(false)
 	return nil, false
 }
 
+// deleteExpired deletes the key if it is still expired
+func (p *LRU) deleteExpired(key any) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if entry, ok := p.items[key]; ok {
+		if _, expired := p.isElementExpired(entry); expired {
+			p.removeElement(entry, EvictReasonExpired)
+		}
+	}
+}
+
+// DeleteExpired Deletes the expired objects, returns the number of them.
+func (p *LRU) DeleteExpired() int {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	n := 0
+	for _, entry := range p.items {
+		if _, expired := p.isElementExpired(entry); expired {
+			p.removeElement(entry, EvictReasonExpired)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats Returns the statistics of the table.
+func (p *LRU) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+// Stop Stops the janitor of the table, the table is still usable.
+func (p *LRU) Stop() {
+	p.janitor.Stop()
+}
+
 func (p *LRU) isElementExpired(e *list.Element) ([]any, bool) {
 	dv := e.Value.(*DataValues)
 	if dv.Expire != nil && dv.Expire.UnixNano() < time.Now().UnixNano() {
@@ -184,8 +246,8 @@ func (p *LRU) isElementExpired(e *list.Element) ([]any, bool) {
 func (p *LRU) LookupAll() (items map[any][]any, ok bool) {
 	p.locker.RLock()
 	for k, v := range p.items {
-		values, ok := p.isElementExpired(v)
-		if ok {
+		values, expired := p.isElementExpired(v)
+		if expired {
 			continue
 		}
 
@@ -196,7 +258,18 @@ func (p *LRU) LookupAll() (items map[any][]any, ok bool) {
 		items[k] = values
 	}
 	p.locker.RUnlock()
-	return
+	return items, items != nil
+}
+
+func (p *LRU) peek(key any) ([]any, bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	entry, ok := p.items[key]
+	if !ok {
+		return nil, false
+	}
+	values, expired := p.isElementExpired(entry)
+	return values, !expired
 }
 
 // Member Returns true if one or more elements in the table has key: Key, otherwise false.
@@ -240,8 +313,7 @@ func (p *LRU) SetExpire(key any, expire time.Duration) bool {
 	expiredTime := time.Now().Add(expire)
 	ent.Expire = &expiredTime
 
-	entry = p.evictList.PushFront(ent)
-	p.items[key] = entry
+	p.evictList.MoveToFront(entry)
 
 	return true
 }
@@ -257,18 +329,27 @@ func (p *LRU) RemoveOldest() (key, value any, ok bool) {
 func (p *LRU) removeOldest() (key, value any, ok bool) {
 	ent := p.evictList.Back()
 	if ent != nil {
-		p.removeElement(ent)
+		p.removeElement(ent, EvictReasonEvicted)
 		kv := ent.Value.(*DataValues)
 		return kv.Key, kv.Values, true
 	}
 	return nil, nil, false
 }
 
-func (p *LRU) removeElement(e *list.Element) {
+func (p *LRU) removeElement(e *list.Element, reason EvictReason) {
 	p.evictList.Remove(e)
 	kv := e.Value.(*DataValues)
 	delete(p.items, kv.Key)
+	p.evicted(kv.Key, kv.Values, reason)
+}
+
+// evicted counts the removed entry and calls the callbacks
+func (p *LRU) evicted(key, values any, reason EvictReason) {
+	p.stats.removed(reason)
 	if p.onEvict != nil {
-		p.onEvict(kv.Key, kv.Values)
+		p.onEvict(key, values)
+	}
+	if p.onEvictReason != nil {
+		p.onEvictReason(key, values, reason)
 	}
 }
@@ -0,0 +1,291 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// tableEntry is the entry of a policyTable, the fields after DataValues belong to the policy
+type tableEntry struct {
+	DataValues
+
+	// element is the element of the entry in the lists of strict lru, fifo and tinylfu
+	element *list.Element
+	// queue is the list of tinylfu holding the entry
+	queue tinyLFUQueue
+	// hash is the hash of the key for the sketch of tinylfu
+	hash uint64
+
+	// index, freq and tick are the position and the order of the entry in the heap of lfu
+	index int
+	freq  uint64
+	tick  uint64
+}
+
+// evictionPolicy orders the entries of a policyTable, it is called with the lock of the table
+type evictionPolicy interface {
+	// add adds the new entry, returns the entries evicted by the size, it may be the new entry
+	add(e *tableEntry) []*tableEntry
+	// access records a hit or an update of the entry
+	access(e *tableEntry)
+	// remove removes the deleted or expired entry
+	remove(e *tableEntry)
+	// reset removes all entries
+	reset()
+}
+
+// policyTable is the TableCache evicting the entries by an evictionPolicy
+type policyTable struct {
+	name string
+
+	locker sync.RWMutex
+	items  map[any]*tableEntry
+	policy evictionPolicy
+
+	onEvict       EvictCallback
+	onEvictReason EvictReasonCallback
+
+	valueMode ValueMode
+
+	stats   tableStats
+	janitor *janitor
+}
+
+func newPolicyTable(name string, opts Options, policy evictionPolicy) (*policyTable, error) {
+	if opts.Size < 0 {
+		return nil, errors.New("must provide a positive size, 0 is unlimited")
+	}
+	c := &policyTable{
+		name:      name,
+		items:     make(map[any]*tableEntry),
+		policy:    policy,
+		valueMode: opts.ValueMode,
+
+		onEvict:       opts.Evict,
+		onEvictReason: opts.EvictReason,
+	}
+	c.janitor = startJanitor(opts.JanitorInterval, func() { c.DeleteExpired() })
+	return c, nil
+}
+
+// Insert a value to the cache.
+func (p *policyTable) Insert(key, value any) bool {
+	return p.InsertExpire(key, value, NoExpire)
+}
+
+// InsertExpire insert a value to the cache. Returns true if insert kv successful.
+func (p *policyTable) InsertExpire(key, value any, expire time.Duration) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	entry, ok := p.items[key]
+	if ok && entry.expired() {
+		p.removeEntry(entry, EvictReasonExpired)
+		ok = false
+	}
+	if !ok {
+		entry = &tableEntry{DataValues: DataValues{Key: key, Exists: make(map[any]bool)}}
+	}
+
+	switch p.valueMode {
+	case ValueModeBag:
+		if !entry.Exists[value] {
+			entry.Values = append(entry.Values, value)
+			entry.Exists[value] = true
+		}
+	case ValueModeDuplicateBag:
+		entry.Values = append(entry.Values, value)
+	case ValueModeUnique:
+		fallthrough
+	default:
+		entry.Values = []any{value}
+	}
+
+	// set expired time
+	if expire > NoExpire {
+		t := time.Now().Add(expire)
+		entry.Expire = &t
+	}
+
+	if ok {
+		p.policy.access(entry)
+		return true
+	}
+
+	p.items[key] = entry
+	for _, victim := range p.policy.add(entry) {
+		delete(p.items, victim.Key)
+		p.evicted(victim.Key, victim.Values, EvictReasonEvicted)
+	}
+	return true
+}
+
+// DeleteObject deletes the provided key from the cache, returning if the key was contained.
+func (p *policyTable) DeleteObject(key any) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	if entry, ok := p.items[key]; ok {
+		p.removeEntry(entry, EvictReasonDeleted)
+		return true
+	}
+	return false
+}
+
+// DeleteObjects is used to completely clear the cache.
+func (p *policyTable) DeleteObjects() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	for k, entry := range p.items {
+		p.evicted(k, entry.Values, EvictReasonDeleted)
+		delete(p.items, k)
+	}
+	p.policy.reset()
+}
+
+// Lookup Look up values with key: Key.
+func (p *policyTable) Lookup(key any) ([]any, bool) {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	entry, ok := p.items[key]
+	if !ok {
+		p.stats.lookup(false)
+		return nil, false
+	}
+	if entry.expired() {
+		p.removeEntry(entry, EvictReasonExpired)
+		p.stats.lookup(false)
+		return nil, false
+	}
+	p.policy.access(entry)
+	p.stats.lookup(true)
+	return entry.Values, true
+}
+
+// LookupAll Look up all key-value pairs.
+func (p *policyTable) LookupAll() (map[any][]any, bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	var items map[any][]any
+	for k, entry := range p.items {
+		if entry.expired() {
+			continue
+		}
+		if items == nil {
+			items = make(map[any][]any)
+		}
+		items[k] = entry.Values
+	}
+	return items, items != nil
+}
+
+func (p *policyTable) peek(key any) ([]any, bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	entry, ok := p.items[key]
+	if !ok || entry.expired() {
+		return nil, false
+	}
+	return entry.Values, true
+}
+
+// Member Returns true if one or more elements in the table has key: Key, otherwise false.
+func (p *policyTable) Member(key any) bool {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	entry, ok := p.items[key]
+	return ok && !entry.expired()
+}
+
+// Members Returns all keys in the table Tab.
+func (p *policyTable) Members() ([]any, bool) {
+	p.locker.RLock()
+	defer p.locker.RUnlock()
+	var keys []any
+	for k, entry := range p.items {
+		if !entry.expired() {
+			keys = append(keys, k)
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+// SetExpire Set Key Expire time
+func (p *policyTable) SetExpire(key any, expire time.Duration) bool {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	entry, ok := p.items[key]
+	if !ok {
+		return false
+	}
+
+	expiredTime := time.Now().Add(expire)
+	entry.Expire = &expiredTime
+	p.policy.access(entry)
+	return true
+}
+
+// DeleteExpired Deletes the expired objects, returns the number of them.
+func (p *policyTable) DeleteExpired() int {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	n := 0
+	for _, entry := range p.items {
+		if entry.expired() {
+			p.removeEntry(entry, EvictReasonExpired)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats Returns the statistics of the table.
+func (p *policyTable) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+// Stop Stops the janitor of the table, the table is still usable.
+func (p *policyTable) Stop() {
+	p.janitor.Stop()
+}
+
+func (p *policyTable) removeEntry(entry *tableEntry, reason EvictReason) {
+	p.policy.remove(entry)
+	delete(p.items, entry.Key)
+	p.evicted(entry.Key, entry.Values, reason)
+}
+
+// evicted counts the removed entry and calls the callbacks
+func (p *policyTable) evicted(key, values any, reason EvictReason) {
+	p.stats.removed(reason)
+	if p.onEvict != nil {
+		p.onEvict(key, values)
+	}
+	if p.onEvictReason != nil {
+		p.onEvictReason(key, values, reason)
+	}
+}
+
+func (p *tableEntry) expired() bool {
+	return p.Expire != nil && p.Expire.UnixNano() < time.Now().UnixNano()
+}
@@ -0,0 +1,367 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+var evictionPolicies = []EvictionPolicy{
+	EvictionPolicyLRU, EvictionPolicyStrictLRU, EvictionPolicyLFU, EvictionPolicyFIFO, EvictionPolicyTinyLFU,
+}
+
+func newPolicyTestTable(t testing.TB, policy EvictionPolicy, opts ...OptionFunc) TableCache {
+	tab, err := NewTableCache("table1", append([]OptionFunc{OptionEvictionPolicy(policy)}, opts...)...)
+	testutils.Ok(t, err)
+	return tab
+}
+
+// tableCacheCase is a TableCache implementation tested by the conformance tests
+type tableCacheCase struct {
+	name string
+	opts []OptionFunc
+	// loading tests the LoadingTable on the table of opts, it is always ValueModeUnique
+	loading bool
+}
+
+func tableCacheCases() []tableCacheCase {
+	var cases []tableCacheCase
+	for _, policy := range evictionPolicies {
+		cases = append(cases, tableCacheCase{name: policy.String(), opts: []OptionFunc{OptionEvictionPolicy(policy)}})
+	}
+	cases = append(cases,
+		tableCacheCase{name: "sharded-lru", opts: []OptionFunc{OptionShards(4)}},
+		tableCacheCase{name: "sharded-tinylfu", opts: []OptionFunc{
+			OptionShards(4), OptionEvictionPolicy(EvictionPolicyTinyLFU),
+		}},
+	)
+	for _, c := range cases {
+		cases = append(cases, tableCacheCase{name: "loading-" + c.name, opts: c.opts, loading: true})
+	}
+	return cases
+}
+
+func newConformanceTable(t testing.TB, c tableCacheCase, opts ...OptionFunc) TableCache {
+	opts = append(append([]OptionFunc{}, c.opts...), opts...)
+	if c.loading {
+		tab, err := NewLoadingTable("table1", opts...)
+		testutils.Ok(t, err)
+		return tab
+	}
+	tab, err := NewTableCache("table1", opts...)
+	testutils.Ok(t, err)
+	return tab
+}
+
+// TestTableCache_Conformance runs the same TableCache tests of all implementations
+func TestTableCache_Conformance(t *testing.T) {
+	for _, c := range tableCacheCases() {
+		t.Run(c.name, func(t *testing.T) {
+			t.Run("InsertLookup", func(t *testing.T) { testPolicyInsertLookup(t, c) })
+			if !c.loading {
+				t.Run("ValueModes", func(t *testing.T) { testPolicyValueModes(t, c) })
+			}
+			t.Run("Delete", func(t *testing.T) { testPolicyDelete(t, c) })
+			t.Run("Expire", func(t *testing.T) { testPolicyExpire(t, c) })
+			t.Run("Size", func(t *testing.T) { testPolicySize(t, c) })
+			t.Run("Janitor", func(t *testing.T) { testPolicyJanitor(t, c) })
+			t.Run("Concurrent", func(t *testing.T) { testPolicyConcurrent(t, c) })
+		})
+	}
+}
+
+func testPolicyInsertLookup(t *testing.T, c tableCacheCase) {
+	tab := newConformanceTable(t, c)
+
+	testutils.Assert(t, tab.Insert("key1", "value1"), "insert should succeed")
+	testutils.Assert(t, tab.Insert("key1", "value2"), "update should succeed")
+	tab.Insert("key2", "value3")
+
+	values, ok := tab.Lookup("key1")
+	testutils.Assert(t, ok, "key1 should exist")
+	testutils.Equals(t, []any{"value2"}, values)
+	_, ok = tab.Lookup("nonexistent")
+	testutils.Assert(t, !ok, "nonexistent should not exist")
+
+	testutils.Assert(t, tab.Member("key2"), "key2 should be a member")
+	keys, ok := tab.Members()
+	testutils.Assert(t, ok, "members should exist")
+	sort.Slice(keys, func(i, j int) bool { return keys[i].(string) < keys[j].(string) })
+	testutils.Equals(t, []any{"key1", "key2"}, keys)
+
+	items, ok := tab.LookupAll()
+	testutils.Assert(t, ok, "items should exist")
+	testutils.Equals(t, map[any][]any{"key1": {"value2"}, "key2": {"value3"}}, items)
+	testutils.Equals(t, Stats{Hits: 1, Misses: 1}, tab.Stats())
+}
+
+func testPolicyValueModes(t *testing.T, c tableCacheCase) {
+	bag := newConformanceTable(t, c, OptionValueMode(ValueModeBag))
+	bag.Insert("key", "a")
+	bag.Insert("key", "a")
+	bag.Insert("key", "b")
+	values, _ := bag.Lookup("key")
+	testutils.Equals(t, []any{"a", "b"}, values)
+
+	duplicate := newConformanceTable(t, c, OptionValueMode(ValueModeDuplicateBag))
+	duplicate.Insert("key", "a")
+	duplicate.Insert("key", "a")
+	values, _ = duplicate.Lookup("key")
+	testutils.Equals(t, []any{"a", "a"}, values)
+}
+
+func testPolicyDelete(t *testing.T, c tableCacheCase) {
+	var reasons []EvictReason
+	tab := newConformanceTable(t, c, OptionEvictReason(func(_, _ any, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}))
+
+	tab.Insert("key1", "value1")
+	tab.Insert("key2", "value2")
+	tab.Insert("key3", "value3")
+	testutils.Assert(t, tab.DeleteObject("key1"), "key1 should be deleted")
+	testutils.Assert(t, !tab.DeleteObject("key1"), "key1 should not be deleted twice")
+	testutils.Assert(t, !tab.Member("key1"), "key1 should not exist")
+
+	tab.DeleteObjects()
+	_, ok := tab.Members()
+	testutils.Assert(t, !ok, "the table should be empty")
+	testutils.Equals(t, []EvictReason{EvictReasonDeleted, EvictReasonDeleted, EvictReasonDeleted}, reasons)
+
+	// the table is usable after DeleteObjects
+	tab.Insert("key4", "value4")
+	testutils.Assert(t, tab.Member("key4"), "key4 should exist")
+	testutils.Assert(t, tab.DeleteObject("key4"), "key4 should be deleted")
+}
+
+func testPolicyExpire(t *testing.T, c tableCacheCase) {
+	tab := newConformanceTable(t, c)
+
+	tab.InsertExpire("key1", "value1", time.Millisecond)
+	tab.InsertExpire("key2", "value2", time.Millisecond)
+	tab.Insert("key3", "value3")
+	testutils.Assert(t, tab.SetExpire("key3", time.Millisecond), "key3 should set expire")
+	testutils.Assert(t, !tab.SetExpire("nonexistent", time.Millisecond), "nonexistent should not set expire")
+	time.Sleep(5 * time.Millisecond)
+
+	testutils.Assert(t, !tab.Member("key1"), "key1 should be expired")
+	_, ok := tab.Lookup("key1")
+	testutils.Assert(t, !ok, "key1 should be expired")
+	_, ok = tab.LookupAll()
+	testutils.Assert(t, !ok, "all keys should be expired")
+
+	// the expired key is inserted again
+	tab.Insert("key2", "value4")
+	values, _ := tab.Lookup("key2")
+	testutils.Equals(t, []any{"value4"}, values)
+
+	testutils.Equals(t, 1, tab.DeleteExpired())
+	testutils.Equals(t, Stats{Hits: 1, Misses: 1, Expirations: 3}, tab.Stats())
+}
+
+func testPolicySize(t *testing.T, c tableCacheCase) {
+	const size = 10
+	evicted := 0
+	tab := newConformanceTable(t, c, OptionKeySize(size),
+		OptionEvictReason(func(_, _ any, reason EvictReason) {
+			testutils.Equals(t, EvictReasonEvicted, reason)
+			evicted++
+		}))
+
+	r := rand.New(rand.NewSource(1))
+	for i := range 1000 {
+		key := r.Intn(50)
+		if _, ok := tab.Lookup(key); !ok {
+			tab.Insert(key, i)
+		}
+		keys, _ := tab.Members()
+		testutils.Assert(t, len(keys) <= size, "the table has %d keys", len(keys))
+	}
+	keys, _ := tab.Members()
+	stats := tab.Stats()
+	testutils.Equals(t, int(stats.Misses)-len(keys), evicted)
+	testutils.Equals(t, uint64(evicted), stats.Evictions)
+	testutils.Equals(t, uint64(1000), stats.Hits+stats.Misses)
+}
+
+func testPolicyJanitor(t *testing.T, c tableCacheCase) {
+	tab := newConformanceTable(t, c, OptionJanitor(5*time.Millisecond))
+	defer tab.Stop()
+
+	tab.InsertExpire("key1", "value1", time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for tab.Stats().Expirations == 0 {
+		testutils.Assert(t, time.Now().Before(deadline), "janitor should delete the expired key")
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func testPolicyConcurrent(t *testing.T, c tableCacheCase) {
+	tab := newConformanceTable(t, c, OptionKeySize(16))
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := range 500 {
+				key := (g*31 + i) % 64
+				if _, ok := tab.Lookup(key); !ok {
+					tab.InsertExpire(key, i, time.Millisecond)
+				}
+				if i%50 == 0 {
+					tab.DeleteObject(key)
+					tab.DeleteExpired()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	keys, _ := tab.Members()
+	testutils.Assert(t, len(keys) <= 16, "the table has %d keys", len(keys))
+}
+
+func TestEvictionPolicy_Orders(t *testing.T) {
+	// key1 is read, then key3 evicts one key of the full table
+	evictedKey := func(policy EvictionPolicy) any {
+		var evicted any
+		tab := newPolicyTestTable(t, policy, OptionKeySize(2), OptionEvict(func(key, _ any) { evicted = key }))
+		tab.Insert("key1", "value1")
+		tab.Insert("key2", "value2")
+		tab.Lookup("key1")
+		tab.Lookup("key1")
+		tab.Insert("key3", "value3")
+		return evicted
+	}
+
+	// the lookups do not change the order of the LRU table
+	testutils.Equals(t, "key1", evictedKey(EvictionPolicyLRU))
+	testutils.Equals(t, "key2", evictedKey(EvictionPolicyStrictLRU))
+	testutils.Equals(t, "key2", evictedKey(EvictionPolicyLFU))
+	testutils.Equals(t, "key1", evictedKey(EvictionPolicyFIFO))
+	// key2 leaves the window, it is less frequent than the victim key1 of the main space
+	testutils.Equals(t, "key2", evictedKey(EvictionPolicyTinyLFU))
+
+	_, err := NewTableCache("table1", OptionEvictionPolicy(EvictionPolicy(100)))
+	testutils.NotOk(t, err)
+	testutils.Equals(t, "unknown", EvictionPolicy(100).String())
+}
+
+func TestEvictionPolicy_TinyLFU_ScanResistant(t *testing.T) {
+	const size = 100
+	tab := newPolicyTestTable(t, EvictionPolicyTinyLFU, OptionKeySize(size))
+
+	// the hot keys are read often, then a scan walks many keys once.
+	// The last inserted key stays in the window until the next insert, the cold key -1 moves the hot keys
+	// into the main space, so that they are promoted by the reads instead of being challenged by the scan.
+	for key := range size / 2 {
+		tab.Insert(key, key)
+	}
+	tab.Insert(-1, -1)
+	for range 5 {
+		for key := range size / 2 {
+			if _, ok := tab.Lookup(key); !ok {
+				tab.Insert(key, key)
+			}
+		}
+	}
+	for key := size; key < 10*size; key++ {
+		if _, ok := tab.Lookup(key); !ok {
+			tab.Insert(key, key)
+		}
+	}
+
+	for key := range size / 2 {
+		testutils.Assert(t, tab.Member(key), "hot key %d should survive the scan", key)
+	}
+}
+
+// BenchmarkEvictionPolicy_HitRatio reports the hit ratio of the policies on the traces, exp:
+//
+//	go test -run none -bench HitRatio ./storage/cache/
+func BenchmarkEvictionPolicy_HitRatio(b *testing.B) {
+	const (
+		size     = 1000
+		keySpace = 100 * size
+		length   = 200000
+	)
+
+	traces := map[string][]int{
+		"zipf": zipfTrace(length, keySpace),
+		"scan": scanTrace(length, keySpace, size),
+	}
+	for _, name := range []string{"zipf", "scan"} {
+		for _, policy := range evictionPolicies {
+			b.Run(fmt.Sprintf("%s/%s", name, policy), func(b *testing.B) {
+				var ratio float64
+				for b.Loop() {
+					ratio = replayTrace(b, policy, size, traces[name])
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
+
+func replayTrace(b *testing.B, policy EvictionPolicy, size int, trace []int) float64 {
+	tab := newPolicyTestTable(b, policy, OptionKeySize(size))
+	hits := 0
+	for _, key := range trace {
+		if _, ok := tab.Lookup(key); ok {
+			hits++
+			continue
+		}
+		tab.Insert(key, key)
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+// zipfTrace returns the skewed keys
+func zipfTrace(length, keySpace int) []int {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(keySpace-1))
+	trace := make([]int, length)
+	for i := range trace {
+		trace[i] = int(zipf.Uint64())
+	}
+	return trace
+}
+
+// scanTrace returns the skewed keys interrupted by the scans walking the keys never read again
+func scanTrace(length, keySpace, size int) []int {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(keySpace-1))
+	trace := make([]int, 0, length)
+	scanKey := keySpace
+	for len(trace) < length {
+		for range 2 * size {
+			trace = append(trace, int(zipf.Uint64()))
+		}
+		for range 2 * size {
+			trace = append(trace, scanKey)
+			scanKey++
+		}
+	}
+	return trace[:length]
+}
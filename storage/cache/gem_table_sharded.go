@@ -0,0 +1,183 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"errors"
+	"hash/maphash"
+	"time"
+)
+
+// OptionShards set the number of shards of the table, it is rounded down to a power of two,
+// every shard has its own lock and the Size is split over the shards; 0 or 1 is not sharded
+func OptionShards(shards int) OptionFunc {
+	return func(t *Options) {
+		t.Shards = shards
+	}
+}
+
+// shardedTable is the TableCache striping the keys over the shards by the hash of the keys
+type shardedTable struct {
+	name string
+
+	seed   maphash.Seed
+	mask   uint64
+	shards []TableCache
+
+	janitor *janitor
+}
+
+func newShardedTable(name string, opts Options) (*shardedTable, error) {
+	if opts.Size < 0 {
+		return nil, errors.New("must provide a positive size, 0 is unlimited")
+	}
+
+	n := opts.Shards
+	if opts.Size > 0 {
+		// every shard has one key at least, 0 is unlimited
+		n = min(n, opts.Size)
+	}
+	shards := 1
+	for shards*2 <= n {
+		shards *= 2
+	}
+
+	p := &shardedTable{
+		name:   name,
+		seed:   maphash.MakeSeed(),
+		mask:   uint64(shards - 1),
+		shards: make([]TableCache, shards),
+	}
+
+	shardOpts := opts
+	shardOpts.Shards = 0
+	// the janitor of the sharded table cleans all shards
+	shardOpts.JanitorInterval = 0
+	for i := range p.shards {
+		if opts.Size > 0 {
+			shardOpts.Size = opts.Size / shards
+			if i < opts.Size%shards {
+				shardOpts.Size++
+			}
+		}
+		shard, err := newTableCache(name, shardOpts)
+		if err != nil {
+			return nil, err
+		}
+		p.shards[i] = shard
+	}
+	p.janitor = startJanitor(opts.JanitorInterval, func() { p.DeleteExpired() })
+	return p, nil
+}
+
+func (p *shardedTable) shard(key any) TableCache {
+	return p.shards[maphash.Comparable(p.seed, key)&p.mask]
+}
+
+// Insert the object or all of the objects in list.
+func (p *shardedTable) Insert(key, value any) bool {
+	return p.shard(key).Insert(key, value)
+}
+
+// InsertExpire insert the object or all of the objects with expired time in list.
+func (p *shardedTable) InsertExpire(key, value any, expire time.Duration) bool {
+	return p.shard(key).InsertExpire(key, value, expire)
+}
+
+// DeleteObject Deletes all objects with key: Key.
+func (p *shardedTable) DeleteObject(key any) bool {
+	return p.shard(key).DeleteObject(key)
+}
+
+// DeleteObjects Delete all objects in the table Tab. Remain table in cache.
+func (p *shardedTable) DeleteObjects() {
+	for _, shard := range p.shards {
+		shard.DeleteObjects()
+	}
+}
+
+// Member Returns true if one or more elements in the table has key: Key, otherwise false.
+func (p *shardedTable) Member(key any) bool {
+	return p.shard(key).Member(key)
+}
+
+func (p *shardedTable) peek(key any) ([]any, bool) {
+	return p.shard(key).(peeker).peek(key)
+}
+
+// Members Returns all keys in the table Tab.
+func (p *shardedTable) Members() ([]any, bool) {
+	var keys []any
+	for _, shard := range p.shards {
+		members, _ := shard.Members()
+		keys = append(keys, members...)
+	}
+	return keys, len(keys) > 0
+}
+
+// Lookup Look up values with key: Key.
+func (p *shardedTable) Lookup(key any) ([]any, bool) {
+	return p.shard(key).Lookup(key)
+}
+
+// LookupAll Look up all values in the Tab.
+func (p *shardedTable) LookupAll() (map[any][]any, bool) {
+	var items map[any][]any
+	for _, shard := range p.shards {
+		values, _ := shard.LookupAll()
+		for k, v := range values {
+			if items == nil {
+				items = make(map[any][]any)
+			}
+			items[k] = v
+		}
+	}
+	return items, items != nil
+}
+
+// SetExpire Set Key Expire time
+func (p *shardedTable) SetExpire(key any, expire time.Duration) bool {
+	return p.shard(key).SetExpire(key, expire)
+}
+
+// DeleteExpired Deletes the expired objects, returns the number of them.
+func (p *shardedTable) DeleteExpired() int {
+	n := 0
+	for _, shard := range p.shards {
+		n += shard.DeleteExpired()
+	}
+	return n
+}
+
+// Stats Returns the statistics of the table.
+func (p *shardedTable) Stats() Stats {
+	var stats Stats
+	for _, shard := range p.shards {
+		s := shard.Stats()
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Evictions += s.Evictions
+		stats.Expirations += s.Expirations
+	}
+	return stats
+}
+
+// Stop Stops the janitor of the table, the table is still usable.
+func (p *shardedTable) Stop() {
+	p.janitor.Stop()
+}
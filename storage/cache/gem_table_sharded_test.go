@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestShardedTable_Shards(t *testing.T) {
+	for _, c := range []struct {
+		shards, size int
+		sizes        []int
+	}{
+		{shards: 4, size: 10, sizes: []int{3, 3, 2, 2}},
+		{shards: 6, size: 0, sizes: []int{0, 0, 0, 0}},
+		{shards: 16, size: 3, sizes: []int{2, 1}},
+	} {
+		tab, err := NewTableCache("table1", OptionShards(c.shards), OptionKeySize(c.size))
+		testutils.Ok(t, err)
+		sharded, ok := tab.(*shardedTable)
+		testutils.Assert(t, ok, "the table should be sharded: %T", tab)
+
+		var sizes []int
+		for _, shard := range sharded.shards {
+			sizes = append(sizes, shard.(*LRU).size)
+		}
+		testutils.Equals(t, c.sizes, sizes)
+	}
+
+	tab, err := NewTableCache("table1", OptionShards(1))
+	testutils.Ok(t, err)
+	_, ok := tab.(*LRU)
+	testutils.Assert(t, ok, "one shard should not be sharded: %T", tab)
+
+	_, err = NewTableCache("table1", OptionShards(4), OptionEvictionPolicy(EvictionPolicy(100)))
+	testutils.NotOk(t, err)
+}
+
+func TestShardedTable_Size(t *testing.T) {
+	const size = 100
+	tab, err := NewTableCache("table1", OptionShards(8), OptionKeySize(size))
+	testutils.Ok(t, err)
+
+	for i := range 10 * size {
+		tab.Insert(i, i)
+	}
+	keys, _ := tab.Members()
+	testutils.Assert(t, len(keys) <= size, "the table has %d keys", len(keys))
+	testutils.Equals(t, uint64(10*size-len(keys)), tab.Stats().Evictions)
+
+	// the latest key is in its shard
+	values, ok := tab.Lookup(10*size - 1)
+	testutils.Assert(t, ok, "the latest key should exist")
+	testutils.Equals(t, []any{10*size - 1}, values)
+}
+
+// BenchmarkTableCache_Parallel compares the LRU and the sharded LRU with 64 goroutines at least, exp:
+//
+//	go test -run none -bench Parallel ./storage/cache/
+func BenchmarkTableCache_Parallel(b *testing.B) {
+	const size = 10000
+	parallelism := (64 + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0)
+
+	for _, c := range []struct {
+		name string
+		opts []OptionFunc
+	}{
+		{name: "lru"},
+		{name: "sharded-16", opts: []OptionFunc{OptionShards(16)}},
+		{name: "sharded-64", opts: []OptionFunc{OptionShards(64)}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			tab, err := NewTableCache("table1", append(c.opts, OptionKeySize(size))...)
+			testutils.Ok(b, err)
+			for i := range size {
+				tab.Insert(i, i)
+			}
+
+			var seed atomic.Int64
+			b.SetParallelism(parallelism)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(seed.Add(1)))
+				for pb.Next() {
+					// 90% reads, 10% writes
+					key := r.Intn(2 * size)
+					if r.Intn(10) == 0 {
+						tab.Insert(key, key)
+						continue
+					}
+					tab.Lookup(key)
+				}
+			})
+			b.ReportMetric(float64(parallelism*runtime.GOMAXPROCS(0)), "goroutines")
+		})
+	}
+}
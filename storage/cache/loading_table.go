@@ -0,0 +1,311 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Loader loads the value of key, return ErrNotFound to cache the missing key for the negative TTL
+type Loader func(ctx context.Context, key any) (any, error)
+
+// OptionLoadTTL set the expired time of the loaded values, 0 is never expired
+func OptionLoadTTL(ttl time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.LoadTTL = ttl
+	}
+}
+
+// OptionRefreshAfter set the soft TTL of the loaded values,
+// the stale values are returned while refreshing them in background, 0 is disabled
+func OptionRefreshAfter(d time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.RefreshAfter = d
+	}
+}
+
+// OptionNegativeTTL set the expired time of ErrNotFound returned by loaders, 0 is not cached
+func OptionNegativeTTL(ttl time.Duration) OptionFunc {
+	return func(t *Options) {
+		t.NegativeTTL = ttl
+	}
+}
+
+// LoadingTable is the ValueModeUnique table loading the missing values by GetOrLoad,
+// the loader is called once per key even under concurrent misses.
+// The value loaded while its key is inserted or deleted is returned to the waiting callers but not cached.
+type LoadingTable struct {
+	TableCache
+
+	loadTTL      time.Duration
+	refreshAfter time.Duration
+	negativeTTL  time.Duration
+	now          func() time.Time
+
+	mu    sync.Mutex
+	calls map[any]*loadCall
+}
+
+// loadedEntry is the value stored by GetOrLoad
+type loadedEntry struct {
+	value    any
+	err      error
+	loadedAt time.Time
+}
+
+// loadCall is an in-flight call of a loader
+type loadCall struct {
+	done  chan struct{}
+	value any
+	err   error
+	// dropped is set if the key is changed while loading, so the loaded value is stale
+	dropped bool
+}
+
+// NewLoadingTable constructs a loading table on the table of the options, exp: OptionEvictionPolicy, OptionShards
+func NewLoadingTable(name string, opts ...OptionFunc) (*LoadingTable, error) {
+	options := Options{}
+	for _, o := range opts {
+		o(&options)
+	}
+	options.ValueMode = ValueModeUnique
+
+	// the callbacks get the loaded values instead of the entries
+	if evict := options.Evict; evict != nil {
+		options.Evict = func(key, value any) { evict(key, unwrapLoadedValues(value)) }
+	}
+	if evict := options.EvictReason; evict != nil {
+		options.EvictReason = func(key, value any, reason EvictReason) {
+			evict(key, unwrapLoadedValues(value), reason)
+		}
+	}
+
+	tab, err := newTableCache(name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadingTable{
+		TableCache:   tab,
+		loadTTL:      options.LoadTTL,
+		refreshAfter: options.RefreshAfter,
+		negativeTTL:  options.NegativeTTL,
+		now:          time.Now,
+		calls:        make(map[any]*loadCall),
+	}, nil
+}
+
+func unwrapLoadedValues(value any) any {
+	values, ok := value.([]any)
+	if !ok {
+		return value
+	}
+	unwrapped := make([]any, 0, len(values))
+	for _, v := range values {
+		if e, ok := v.(*loadedEntry); ok {
+			if e.err != nil {
+				continue
+			}
+			v = e.value
+		}
+		unwrapped = append(unwrapped, v)
+	}
+	return unwrapped
+}
+
+// GetOrLoad returns the value of key, or loads it by the loader if it is missing.
+//
+// The loader runs without the cancellation of ctx, so that the callers waiting for the same key
+// are not failed by the first one; a caller stops waiting when its ctx is done.
+func (p *LoadingTable) GetOrLoad(ctx context.Context, key any, loader Loader) (any, error) {
+	if e, ok := p.lookup(key); ok {
+		if e.err != nil {
+			return nil, e.err
+		}
+		if p.isStale(e) {
+			p.refresh(ctx, key, loader)
+		}
+		return e.value, nil
+	}
+
+	call := p.load(ctx, key, loader)
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *LoadingTable) isStale(e *loadedEntry) bool {
+	return p.refreshAfter > 0 && !e.loadedAt.IsZero() && p.now().Sub(e.loadedAt) >= p.refreshAfter
+}
+
+// refresh loads the value in background, the stale value is kept if the loader fails
+func (p *LoadingTable) refresh(ctx context.Context, key any, loader Loader) {
+	p.load(ctx, key, loader)
+}
+
+// load returns the in-flight call of key, or starts a new one
+func (p *LoadingTable) load(ctx context.Context, key any, loader Loader) *loadCall {
+	p.mu.Lock()
+	if call, ok := p.calls[key]; ok {
+		p.mu.Unlock()
+		return call
+	}
+	call := &loadCall{done: make(chan struct{})}
+	p.calls[key] = call
+	p.mu.Unlock()
+
+	go func() {
+		defer close(call.done)
+
+		call.value, call.err = callLoader(context.WithoutCancel(ctx), key, loader)
+
+		// the value is inserted in the lock, so that it is either dropped or deleted by the changes of key
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if call.dropped {
+			return
+		}
+		delete(p.calls, key)
+
+		switch {
+		case call.err == nil:
+			p.TableCache.InsertExpire(key, &loadedEntry{value: call.value, loadedAt: p.now()}, p.loadTTL)
+		case errors.Is(call.err, ErrNotFound) && p.negativeTTL > 0:
+			p.TableCache.InsertExpire(key, &loadedEntry{err: call.err, loadedAt: p.now()}, p.negativeTTL)
+		}
+	}()
+	return call
+}
+
+// drop drops the in-flight calls of the changed keys, all of the calls if no key is set
+func (p *LoadingTable) drop(keys ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(keys) == 0 {
+		for _, call := range p.calls {
+			call.dropped = true
+		}
+		p.calls = make(map[any]*loadCall)
+		return
+	}
+	for _, key := range keys {
+		if call, ok := p.calls[key]; ok {
+			call.dropped = true
+			delete(p.calls, key)
+		}
+	}
+}
+
+// Insert inserts the value of key, the value being loaded is dropped
+func (p *LoadingTable) Insert(key, value any) bool {
+	p.drop(key)
+	return p.TableCache.Insert(key, value)
+}
+
+// InsertExpire inserts the value of key with the expired time, the value being loaded is dropped
+func (p *LoadingTable) InsertExpire(key, value any, expire time.Duration) bool {
+	p.drop(key)
+	return p.TableCache.InsertExpire(key, value, expire)
+}
+
+// DeleteObject deletes the value of key, the value being loaded is dropped
+func (p *LoadingTable) DeleteObject(key any) bool {
+	p.drop(key)
+	return p.TableCache.DeleteObject(key)
+}
+
+// DeleteObjects deletes all values, the values being loaded are dropped
+func (p *LoadingTable) DeleteObjects() {
+	p.drop()
+	p.TableCache.DeleteObjects()
+}
+
+func callLoader(ctx context.Context, key any, loader Loader) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cache loader panic: %v", r)
+		}
+	}()
+	return loader(ctx, key)
+}
+
+// lookup returns the entry of key, the values inserted by Insert are never stale
+func (p *LoadingTable) lookup(key any) (*loadedEntry, bool) {
+	values, ok := p.TableCache.Lookup(key)
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	if e, ok := values[0].(*loadedEntry); ok {
+		return e, true
+	}
+	return &loadedEntry{value: values[0]}, true
+}
+
+// Lookup Look up values with key: Key, the cached ErrNotFound is not found.
+func (p *LoadingTable) Lookup(key any) ([]any, bool) {
+	e, ok := p.lookup(key)
+	if !ok || e.err != nil {
+		return nil, false
+	}
+	return []any{e.value}, true
+}
+
+// LookupAll Look up all values in the Tab.
+func (p *LoadingTable) LookupAll() (map[any][]any, bool) {
+	items, _ := p.TableCache.LookupAll()
+	for k, values := range items {
+		values = unwrapLoadedValues(values).([]any)
+		if len(values) == 0 {
+			delete(items, k)
+			continue
+		}
+		items[k] = values
+	}
+	return items, len(items) > 0
+}
+
+// Member Returns true if the table has key: Key, the cached ErrNotFound is not a member.
+func (p *LoadingTable) Member(key any) bool {
+	values, ok := p.TableCache.(peeker).peek(key)
+	if !ok || len(values) == 0 {
+		return false
+	}
+	e, ok := values[0].(*loadedEntry)
+	return !ok || e.err == nil
+}
+
+// Members Returns all keys in the table Tab.
+func (p *LoadingTable) Members() ([]any, bool) {
+	items, ok := p.LookupAll()
+	if !ok {
+		return nil, false
+	}
+	keys := make([]any, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	return keys, true
+}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestLoadingTable_SingleFlight(t *testing.T) {
+	table, err := NewLoadingTable("users")
+	testutils.Ok(t, err)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key any) (any, error) {
+		calls.Add(1)
+		<-release
+		return "henry", nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]any, 20)
+	errs := make([]error, len(values))
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], errs[i] = table.GetOrLoad(context.Background(), 1, loader)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	testutils.Equals(t, int32(1), calls.Load())
+	for i, v := range values {
+		testutils.Ok(t, errs[i])
+		testutils.Equals(t, "henry", v)
+	}
+
+	v, err := table.GetOrLoad(context.Background(), 1, loader)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "henry", v)
+	testutils.Equals(t, int32(1), calls.Load())
+
+	values, ok := table.Lookup(1)
+	testutils.Assert(t, ok, "key 1 should be loaded")
+	testutils.Equals(t, []any{"henry"}, values)
+	items, _ := table.LookupAll()
+	testutils.Equals(t, map[any][]any{1: {"henry"}}, items)
+}
+
+func TestLoadingTable_Errors(t *testing.T) {
+	table, err := NewLoadingTable("users", OptionNegativeTTL(20*time.Millisecond))
+	testutils.Ok(t, err)
+
+	var calls atomic.Int32
+	errDB := errors.New("db is down")
+	loader := func(ctx context.Context, key any) (any, error) {
+		calls.Add(1)
+		switch key {
+		case "missing":
+			return nil, ErrNotFound
+		case "panic":
+			panic("boom")
+		default:
+			return nil, errDB
+		}
+	}
+
+	// the not found result is cached for the negative TTL
+	for range 3 {
+		_, err = table.GetOrLoad(context.Background(), "missing", loader)
+		testutils.Assert(t, errors.Is(err, ErrNotFound), "err should be not found: %v", err)
+	}
+	testutils.Equals(t, int32(1), calls.Load())
+	testutils.Assert(t, !table.Member("missing"), "the negative entry should not be a member")
+	members, _ := table.Members()
+	testutils.Equals(t, 0, len(members))
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = table.GetOrLoad(context.Background(), "missing", loader)
+	testutils.Assert(t, errors.Is(err, ErrNotFound), "err should be not found: %v", err)
+	testutils.Equals(t, int32(2), calls.Load())
+
+	// the other errors are not cached
+	for range 2 {
+		_, err = table.GetOrLoad(context.Background(), "db", loader)
+		testutils.Equals(t, errDB, err)
+	}
+	testutils.Equals(t, int32(4), calls.Load())
+
+	_, err = table.GetOrLoad(context.Background(), "panic", loader)
+	testutils.NotOk(t, err)
+
+	// the waiting caller stops with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = table.GetOrLoad(ctx, "slow", func(ctx context.Context, key any) (any, error) {
+		time.Sleep(50 * time.Millisecond)
+		return "slow", ctx.Err()
+	})
+	testutils.Equals(t, context.DeadlineExceeded, err)
+	time.Sleep(60 * time.Millisecond)
+	testutils.Assert(t, table.Member("slow"), "the loader should not be canceled by the caller")
+}
+
+func TestLoadingTable_RefreshAfter(t *testing.T) {
+	var evicted []any
+	table, err := NewLoadingTable("users",
+		OptionRefreshAfter(20*time.Millisecond),
+		OptionLoadTTL(time.Second),
+		OptionEvict(func(key, value any) { evicted = append(evicted, value) }),
+	)
+	testutils.Ok(t, err)
+
+	var version atomic.Int32
+	var fail atomic.Bool
+	loaded := make(chan struct{}, 10)
+	loader := func(ctx context.Context, key any) (any, error) {
+		defer func() { loaded <- struct{}{} }()
+		if fail.Load() {
+			return nil, errors.New("failed")
+		}
+		return version.Add(1), nil
+	}
+
+	v, err := table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Ok(t, err)
+	testutils.Equals(t, int32(1), v)
+	<-loaded
+
+	// the fresh value is returned without loading
+	v, _ = table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Equals(t, int32(1), v)
+	testutils.Equals(t, 0, len(loaded))
+
+	// the stale value is returned while refreshing
+	time.Sleep(30 * time.Millisecond)
+	v, _ = table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Equals(t, int32(1), v)
+	<-loaded
+	time.Sleep(5 * time.Millisecond)
+	v, _ = table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Equals(t, int32(2), v)
+
+	// the stale value is kept if refreshing fails
+	fail.Store(true)
+	time.Sleep(30 * time.Millisecond)
+	v, _ = table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Equals(t, int32(2), v)
+	<-loaded
+	time.Sleep(5 * time.Millisecond)
+	v, err = table.GetOrLoad(context.Background(), "k", loader)
+	testutils.Ok(t, err)
+	testutils.Equals(t, int32(2), v)
+	<-loaded
+
+	// the evict callback gets the loaded values
+	testutils.Assert(t, table.DeleteObject("k"), "k should be deleted")
+	testutils.Equals(t, []any{[]any{int32(2)}}, evicted)
+}
+
+func TestLoadingTable_Invalidate(t *testing.T) {
+	table, err := NewLoadingTable("users")
+	testutils.Ok(t, err)
+
+	var version atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key any) (any, error) {
+		<-release
+		return version.Add(1), nil
+	}
+
+	for _, c := range []struct {
+		invalidate func()
+		expected   []any
+	}{
+		{invalidate: func() { table.DeleteObject("k") }},
+		{invalidate: func() { table.DeleteObjects() }},
+		{invalidate: func() { table.Insert("k", int32(0)) }, expected: []any{int32(0)}},
+	} {
+		table.DeleteObjects()
+		release = make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := table.GetOrLoad(context.Background(), "k", loader)
+			done <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		// the value loaded before the change is returned but not cached
+		c.invalidate()
+		close(release)
+		testutils.Ok(t, <-done)
+		values, _ := table.Lookup("k")
+		testutils.Equals(t, c.expected, values)
+
+		table.DeleteObject("k")
+		v, err := table.GetOrLoad(context.Background(), "k", loader)
+		testutils.Ok(t, err)
+		testutils.Equals(t, version.Load(), v)
+	}
+}
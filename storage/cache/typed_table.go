@@ -0,0 +1,165 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"time"
+)
+
+// TypedTable is the type-safe table of keys K and values V on a TableCache,
+// it keeps the semantics of the ValueMode of the table:
+// use Get for ValueModeUnique tables, and GetAll for ValueModeBag and ValueModeDuplicateBag tables.
+//
+// The values of other types inserted by the TableCache are ignored.
+type TypedTable[K comparable, V any] struct {
+	table TableCache
+}
+
+// NewTyped constructs a typed table on a new TableCache of the options
+func NewTyped[K comparable, V any](name string, opts ...OptionFunc) (*TypedTable[K, V], error) {
+	table, err := NewTableCache(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return WrapTyped[K, V](table), nil
+}
+
+// WrapTyped returns the typed table on the table
+func WrapTyped[K comparable, V any](table TableCache) *TypedTable[K, V] {
+	return &TypedTable[K, V]{table: table}
+}
+
+// GetTyped returns the typed table of the table Tab in the cache
+func GetTyped[K comparable, V any](c Cache, tab string) (*TypedTable[K, V], bool) {
+	table, ok := c.GetTableCache(tab)
+	if !ok {
+		return nil, false
+	}
+	return WrapTyped[K, V](table), true
+}
+
+// Table returns the underlying TableCache
+func (p *TypedTable[K, V]) Table() TableCache {
+	return p.table
+}
+
+// Insert inserts the value of key
+func (p *TypedTable[K, V]) Insert(key K, value V) bool {
+	return p.table.Insert(key, value)
+}
+
+// InsertExpire inserts the value of key with expired time
+func (p *TypedTable[K, V]) InsertExpire(key K, value V, expire time.Duration) bool {
+	return p.table.InsertExpire(key, value, expire)
+}
+
+// Get returns the first value of key, it is the only value of ValueModeUnique tables
+func (p *TypedTable[K, V]) Get(key K) (V, bool) {
+	values, ok := p.table.Lookup(key)
+	if ok {
+		for _, value := range values {
+			if v, ok := value.(V); ok {
+				return v, true
+			}
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// GetAll returns all values of key
+func (p *TypedTable[K, V]) GetAll(key K) []V {
+	values, _ := p.table.Lookup(key)
+	return typedValues[V](values)
+}
+
+// All returns all values of all keys
+func (p *TypedTable[K, V]) All() map[K][]V {
+	items, _ := p.table.LookupAll()
+	all := make(map[K][]V, len(items))
+	for key, values := range items {
+		k, ok := key.(K)
+		if !ok {
+			continue
+		}
+		if vs := typedValues[V](values); len(vs) > 0 {
+			all[k] = vs
+		}
+	}
+	return all
+}
+
+func typedValues[V any](values []any) []V {
+	if len(values) == 0 {
+		return nil
+	}
+	vs := make([]V, 0, len(values))
+	for _, value := range values {
+		if v, ok := value.(V); ok {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// Member returns true if the table has key
+func (p *TypedTable[K, V]) Member(key K) bool {
+	return p.table.Member(key)
+}
+
+// Keys returns all keys
+func (p *TypedTable[K, V]) Keys() []K {
+	members, _ := p.table.Members()
+	keys := make([]K, 0, len(members))
+	for _, member := range members {
+		if k, ok := member.(K); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Delete deletes all values of key
+func (p *TypedTable[K, V]) Delete(key K) bool {
+	return p.table.DeleteObject(key)
+}
+
+// DeleteAll deletes all keys, the table remains
+func (p *TypedTable[K, V]) DeleteAll() {
+	p.table.DeleteObjects()
+}
+
+// SetExpire sets the expired time of key
+func (p *TypedTable[K, V]) SetExpire(key K, expire time.Duration) bool {
+	return p.table.SetExpire(key, expire)
+}
+
+// DeleteExpired deletes the expired keys, returns the number of them
+func (p *TypedTable[K, V]) DeleteExpired() int {
+	return p.table.DeleteExpired()
+}
+
+// Stats returns the statistics of the table
+func (p *TypedTable[K, V]) Stats() Stats {
+	return p.table.Stats()
+}
+
+// Stop stops the janitor of the table
+func (p *TypedTable[K, V]) Stop() {
+	p.table.Stop()
+}
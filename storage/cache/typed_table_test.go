@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cache
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+type typedUser struct {
+	ID   int
+	Name string
+}
+
+func TestTypedTable_Unique(t *testing.T) {
+	users, err := NewTyped[int, *typedUser]("users", OptionKeySize(2))
+	testutils.Ok(t, err)
+
+	users.Insert(1, &typedUser{ID: 1, Name: "henry"})
+	users.Insert(1, &typedUser{ID: 1, Name: "huang"})
+	users.InsertExpire(2, &typedUser{ID: 2, Name: "expired"}, time.Millisecond)
+
+	u, ok := users.Get(1)
+	testutils.Assert(t, ok, "user 1 should exist")
+	testutils.Equals(t, "huang", u.Name)
+	testutils.Equals(t, 1, len(users.GetAll(1)))
+
+	time.Sleep(5 * time.Millisecond)
+	u, ok = users.Get(2)
+	testutils.Assert(t, !ok && u == nil, "user 2 should be expired")
+
+	testutils.Equals(t, []int{1}, users.Keys())
+	testutils.Equals(t, map[int][]*typedUser{1: {{ID: 1, Name: "huang"}}}, users.All())
+
+	// the values of other types are ignored
+	users.Table().Insert(3, "not a user")
+	_, ok = users.Get(3)
+	testutils.Assert(t, !ok, "the string value should be ignored")
+	testutils.Assert(t, users.Member(3), "key 3 is still a member")
+
+	testutils.Assert(t, users.Delete(1), "user 1 should be deleted")
+	_, ok = users.Get(1)
+	testutils.Assert(t, !ok, "user 1 should not exist")
+	testutils.Equals(t, Stats{Hits: 3, Misses: 2, Expirations: 1}, users.Stats())
+}
+
+func TestTypedTable_Bag(t *testing.T) {
+	c := New()
+	testutils.Ok(t, c.New("tags", OptionValueMode(ValueModeBag)))
+	testutils.Ok(t, c.New("events", OptionValueMode(ValueModeDuplicateBag)))
+
+	tags, ok := GetTyped[string, string](c, "tags")
+	testutils.Assert(t, ok, "tags should exist")
+	tags.Insert("go", "lang")
+	tags.Insert("go", "lang")
+	tags.Insert("go", "google")
+	testutils.Equals(t, []string{"lang", "google"}, tags.GetAll("go"))
+	first, _ := tags.Get("go")
+	testutils.Equals(t, "lang", first)
+
+	events, _ := GetTyped[string, int](c, "events")
+	events.Insert("clicks", 1)
+	events.Insert("clicks", 1)
+	testutils.Equals(t, []int{1, 1}, events.GetAll("clicks"))
+	testutils.Equals(t, 0, len(events.GetAll("views")))
+
+	// the typed tables work with the Cache interface
+	values, ok := c.Lookup("tags", "go")
+	testutils.Assert(t, ok, "go should exist")
+	testutils.Equals(t, []any{"lang", "google"}, values)
+
+	c.Insert("tags", "rust", "lang")
+	keys := tags.Keys()
+	sort.Strings(keys)
+	testutils.Equals(t, []string{"go", "rust"}, keys)
+
+	tags.DeleteAll()
+	testutils.Equals(t, 0, len(tags.All()))
+
+	_, ok = GetTyped[string, string](c, "nonexistent")
+	testutils.Assert(t, !ok, "nonexistent table should not exist")
+}
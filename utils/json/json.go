@@ -27,6 +27,7 @@ type Number = json.Number
 type RawMessage = json.RawMessage
 type Delim = json.Delim
 type Token = json.Token
+type Marshaler = json.Marshaler
 
 func Unmarshal(bs []byte, v any) error {
 	return json.Unmarshal(bs, v)
@@ -85,6 +85,20 @@ func True(tb testing.TB, expert bool, msgAndArgs ...any) {
 	tb.Fatalf("%s\n\nexp: TRUE \n\ngot: FALSE\n", formatMessage(msgAndArgs))
 }
 
+// FieldRecorder is the logger recording the entries, exp: logger.Recorder
+type FieldRecorder interface {
+	ContainsField(key string, value any) bool
+	String() string
+}
+
+// ContainsField fails the test if no entry recorded by rec has the field key equal to value.
+func ContainsField(tb testing.TB, rec FieldRecorder, key string, value any, msgAndArgs ...any) {
+	tb.Helper()
+	if !rec.ContainsField(key, value) {
+		tb.Fatalf("%s\n\nexp: entry with %s=%v\n\ngot:\n%s\n", formatMessage(msgAndArgs), key, value, rec)
+	}
+}
+
 func formatMessage(msgAndArgs []any) string {
 	if len(msgAndArgs) == 0 {
 		return ""
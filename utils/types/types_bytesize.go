@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package types
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-trellis/common/utils/json"
+)
+
+var _ flag.Value = (*ByteSize)(nil)
+
+// ByteSize is a size in bytes, exp: 512, 10kb, 64mib
+type ByteSize int64
+
+// ParseByteSize parses the size in bytes, or with units of ParseStringByteSize.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ByteSize(i), nil
+	}
+
+	size := ParseStringByteSize(s)
+	if size == nil || !size.IsInt64() {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	return ByteSize(size.Int64()), nil
+}
+
+// String implements the fmt.Stringer interface.
+func (p ByteSize) String() string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i, size := 0, int64(p)
+	for ; i < len(units)-1 && size != 0 && size%1024 == 0; i++ {
+		size /= 1024
+	}
+	return fmt.Sprintf("%d%s", size, units[i])
+}
+
+// Set implements flag.Value
+func (p *ByteSize) Set(s string) error {
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*p = size
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p ByteSize) MarshalYAML() (any, error) {
+	return p.String(), nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *ByteSize) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return p.Set(s)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *ByteSize) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	return p.Set(s)
+}
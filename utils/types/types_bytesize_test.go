@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/testutils"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for s, size := range map[string]ByteSize{
+		"512":   512,
+		"10kb":  10000,
+		"10k":   10240,
+		"64MiB": 64 << 20,
+		" 1g ":  1 << 30,
+	} {
+		v, err := ParseByteSize(s)
+		testutils.Ok(t, err)
+		testutils.Equals(t, size, v, "size of %q", s)
+	}
+
+	_, err := ParseByteSize("10 apples")
+	testutils.NotOk(t, err)
+}
+
+func TestByteSize_String(t *testing.T) {
+	testutils.Equals(t, "0B", ByteSize(0).String())
+	testutils.Equals(t, "1000B", ByteSize(1000).String())
+	testutils.Equals(t, "64MiB", ByteSize(64<<20).String())
+}
+
+func TestByteSize_Unmarshal(t *testing.T) {
+	var v struct {
+		Size ByteSize `yaml:"size" json:"size"`
+	}
+	testutils.Ok(t, yaml.Unmarshal([]byte("size: 10mib"), &v))
+	testutils.Equals(t, ByteSize(10<<20), v.Size)
+	testutils.NotOk(t, yaml.Unmarshal([]byte("size: big"), &v))
+
+	testutils.Ok(t, json.Unmarshal([]byte(`{"size": 2048}`), &v))
+	testutils.Equals(t, ByteSize(2048), v.Size)
+	testutils.Ok(t, json.Unmarshal([]byte(`{"size": "1k"}`), &v))
+	testutils.Equals(t, ByteSize(1024), v.Size)
+
+	bs, err := yaml.Marshal(v)
+	testutils.Ok(t, err)
+	testutils.Equals(t, "size: 1KiB\n", string(bs))
+}
@@ -20,7 +20,11 @@ package types
 import (
 	"flag"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/go-trellis/common/utils/json"
 )
 
 var _ flag.Value = (*Duration)(nil)
@@ -109,3 +113,42 @@ func (p *Duration) Set(s string) error {
 	*p = Duration(ParseStringTime(s))
 	return nil
 }
+
+// ParseDuration parses the duration by time.ParseDuration, exp: 1h30m, or with units of ParseStringTime, exp: 1day.
+func ParseDuration(s string) (Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if d, err := time.ParseDuration(s); err == nil {
+		return Duration(d), nil
+	}
+	if _, matched := FindStringSubmatchMap(s, timeReg); !matched {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return Duration(ParseStringTime(s)), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface,
+// numbers are nanoseconds like time.Duration, and strings are parsed by ParseDuration.
+func (p *Duration) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(s, `"`) {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration %s", s)
+		}
+		*p = Duration(i)
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*p = d
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2025 Henry Huang <hhh@rutcode.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-trellis/common/utils/json"
+	"github.com/go-trellis/common/utils/testutils"
+)
+
+func TestParseDuration(t *testing.T) {
+	for s, d := range map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"1h30m": 90 * time.Minute,
+		"1.5h":  90 * time.Minute,
+		"1day":  24 * time.Hour,
+		" 2W ":  14 * 24 * time.Hour,
+		"0":     0,
+	} {
+		v, err := ParseDuration(s)
+		testutils.Ok(t, err)
+		testutils.Equals(t, Duration(d), v, "duration of %q", s)
+	}
+
+	for _, s := range []string{"", "30", "abc", "1x"} {
+		_, err := ParseDuration(s)
+		testutils.NotOk(t, err)
+	}
+}
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		Timeout Duration `json:"timeout"`
+		TTL     Duration `json:"ttl"`
+	}
+	testutils.Ok(t, json.Unmarshal([]byte(`{"timeout": "1day", "ttl": 1500000000}`), &v))
+	testutils.Equals(t, Duration(24*time.Hour), v.Timeout)
+	// numbers are nanoseconds like time.Duration
+	testutils.Equals(t, Duration(1500*time.Millisecond), v.TTL)
+
+	testutils.NotOk(t, json.Unmarshal([]byte(`{"timeout": "soon"}`), &v))
+	testutils.NotOk(t, json.Unmarshal([]byte(`{"timeout": 1.5}`), &v))
+	testutils.Ok(t, json.Unmarshal([]byte(`{"timeout": null}`), &v))
+	testutils.Equals(t, Duration(24*time.Hour), v.Timeout)
+}